@@ -0,0 +1,155 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// AIMDLimiter is a Limiter whose rate adapts to the feedback of the
+// operations it paces, with additive increase and multiplicative decrease
+// (AIMD), like TCP congestion control: it ramps up slowly while operations
+// succeed, and backs off quickly when a downstream reports it's overloaded.
+//
+// It paces permissions like the limiters returned by New, and implements
+// the same interfaces, except for AdjustableLimiter.
+type AIMDLimiter struct {
+	limiter
+
+	// adjust changes the rate of the limiter.
+	adjust   func(Rate) error
+	min, max float64
+	increase float64
+	decrease float64
+	per      time.Duration
+
+	mu           sync.Mutex
+	rate         float64
+	lastDecrease time.Time
+	clock        Clock
+}
+
+// NewAIMD returns an AIMDLimiter that starts at the given rate, and adapts
+// it between min and max, which must be positive and finite. The options
+// apply to the limiter, and may include WithAdditiveIncrease and
+// WithMultiplicativeDecrease to tune how fast it adapts.
+func NewAIMD(rate, min, max Rate, opts ...Option) (*AIMDLimiter, error) {
+	config := buildConfig(opts)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if !(min > 0 && min <= rate && rate <= max && max < Inf) {
+		return nil, fmt.Errorf("ratelimit: rates must be finite and 0 < min <= rate <= max, got %v, %v and %v",
+			float64(min), float64(rate), float64(max))
+	}
+	if err := checkRateLow(min, config.per); err != nil {
+		return nil, err
+	}
+	if math.IsNaN(float64(config.increase)) || config.increase <= 0 || config.increase >= Inf {
+		return nil, fmt.Errorf("ratelimit: additive increase must be positive and finite, got %v", float64(config.increase))
+	}
+	if !(config.decrease > 0 && config.decrease < 1) {
+		return nil, fmt.Errorf("ratelimit: multiplicative decrease must be between 0 and 1, got %v", config.decrease)
+	}
+
+	l := newAtomicInt64Based(rate, opts...)
+	return &AIMDLimiter{
+		limiter:  l,
+		adjust:   l.SetRate,
+		min:      float64(min),
+		max:      float64(max),
+		increase: float64(config.increase),
+		decrease: config.decrease,
+		per:      config.per,
+		rate:     float64(rate),
+		clock:    config.clock,
+	}, nil
+}
+
+// Success reports an operation that succeeded. The rate grows by the
+// additive increase for every window's worth of successes, that is, by
+// the increase divided by the rate for each of them, up to max.
+func (a *AIMDLimiter) Success() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.set(a.rate + a.increase/a.rate)
+}
+
+// Overload reports an operation that failed because the downstream is
+// overloaded, such as a 429 or 503 response. The rate is multiplied by
+// the multiplicative decrease, down to min.
+//
+// Operations that were in flight at the old rate are likely to fail too,
+// so the rate decreases at most once per window: one second, unless
+// configured otherwise with Per.
+func (a *AIMDLimiter) Overload() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.clock.Now()
+	if !a.lastDecrease.IsZero() && now.Sub(a.lastDecrease) < a.per {
+		return
+	}
+	a.lastDecrease = now
+	a.set(a.rate * a.decrease)
+}
+
+// set changes the rate within its bounds. It must be called with the lock
+// held.
+func (a *AIMDLimiter) set(rate float64) {
+	rate = math.Max(a.min, math.Min(a.max, rate))
+	if rate == a.rate {
+		return
+	}
+	a.rate = rate
+	// The rate is positive, so the limiter accepts it.
+	_ = a.adjust(Rate(rate))
+}
+
+type increaseOption Rate
+
+func (o increaseOption) apply(c *config) {
+	c.increase = Rate(o)
+}
+
+// WithAdditiveIncrease configures how much the rate of an AIMDLimiter
+// grows for every window's worth of successful operations. It's one by
+// default. It has no effect on other limiters.
+func WithAdditiveIncrease(increase Rate) Option {
+	return increaseOption(increase)
+}
+
+type decreaseOption float64
+
+func (o decreaseOption) apply(c *config) {
+	c.decrease = float64(o)
+}
+
+// WithMultiplicativeDecrease configures the factor, between 0 and 1, by
+// which an AIMDLimiter multiplies its rate on Overload. It's 0.5 by
+// default. It has no effect on other limiters.
+func WithMultiplicativeDecrease(factor float64) Option {
+	return decreaseOption(factor)
+}
@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAIMDInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		msg            string
+		rate, min, max Rate
+		opts           []Option
+		wantErr        string
+	}{
+		{
+			msg:  "zero min",
+			rate: 10, min: 0, max: 100,
+			wantErr: "ratelimit: rates must be finite and 0 < min <= rate <= max, got 0, 10 and 100",
+		},
+		{
+			msg:  "rate over max",
+			rate: 200, min: 1, max: 100,
+			wantErr: "ratelimit: rates must be finite and 0 < min <= rate <= max, got 1, 200 and 100",
+		},
+		{
+			msg:  "infinite max",
+			rate: 10, min: 1, max: Rate(math.Inf(1)),
+			wantErr: "ratelimit: rates must be finite and 0 < min <= rate <= max, got 1, 10 and +Inf",
+		},
+		{
+			msg:  "NaN",
+			rate: Rate(math.NaN()), min: 1, max: 100,
+			wantErr: "ratelimit: rates must be finite and 0 < min <= rate <= max, got 1, NaN and 100",
+		},
+		{
+			msg:  "zero increase",
+			rate: 10, min: 1, max: 100,
+			opts:    []Option{WithAdditiveIncrease(0)},
+			wantErr: "ratelimit: additive increase must be positive and finite, got 0",
+		},
+		{
+			msg:  "decrease of one",
+			rate: 10, min: 1, max: 100,
+			opts:    []Option{WithMultiplicativeDecrease(1)},
+			wantErr: "ratelimit: multiplicative decrease must be between 0 and 1, got 1",
+		},
+		{
+			msg:  "invalid option",
+			rate: 10, min: 1, max: 100,
+			opts:    []Option{WithSlack(-1)},
+			wantErr: "ratelimit: slack must not be negative, got -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			a, err := NewAIMD(tt.rate, tt.min, tt.max, tt.opts...)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, a)
+		})
+	}
+}
+
+func TestAIMDIncrease(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	a, err := NewAIMD(10, 1, 12, WithClock(clk), WithAdditiveIncrease(2))
+	require.NoError(t, err)
+
+	// A window's worth of successes adds about the increase.
+	for i := 0; i < 10; i++ {
+		a.Success()
+	}
+	assert.InDelta(t, 11.8, float64(a.Stats().Rate), 0.1)
+
+	for i := 0; i < 10; i++ {
+		a.Success()
+	}
+	assert.Equal(t, Rate(12), a.Stats().Rate, "shouldn't grow over max")
+}
+
+func TestAIMDDecrease(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	a, err := NewAIMD(100, 10, 100, WithClock(clk))
+	require.NoError(t, err)
+
+	a.Overload()
+	assert.Equal(t, Rate(50), a.Stats().Rate)
+
+	// Operations in flight at the old rate fail too.
+	a.Overload()
+	clk.Add(999 * time.Millisecond)
+	a.Overload()
+	assert.Equal(t, Rate(50), a.Stats().Rate, "should decrease once per window")
+
+	clk.Add(time.Millisecond)
+	a.Overload()
+	assert.Equal(t, Rate(25), a.Stats().Rate)
+
+	for i := 0; i < 3; i++ {
+		clk.Add(time.Second)
+		a.Overload()
+	}
+	assert.Equal(t, Rate(10), a.Stats().Rate, "shouldn't decrease under min")
+}
+
+func TestAIMDDecreaseWindow(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	a, err := NewAIMD(60, 1, 60, WithClock(clk), Per(time.Minute), WithMultiplicativeDecrease(0.8))
+	require.NoError(t, err)
+
+	a.Overload()
+	assert.InDelta(t, 48, float64(a.Stats().Rate), 1e-9)
+	clk.Add(30 * time.Second)
+	a.Overload()
+	assert.InDelta(t, 48, float64(a.Stats().Rate), 1e-9, "window is a minute")
+	clk.Add(30 * time.Second)
+	a.Overload()
+	assert.InDelta(t, 38.4, float64(a.Stats().Rate), 1e-9)
+}
+
+func TestAIMDPacing(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	a, err := NewAIMD(10, 1, 10, WithClock(clk), WithoutSlack)
+	require.NoError(t, err)
+
+	ok, _ := a.TryTake()
+	require.True(t, ok)
+	ok, retryAfter := a.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	// Backing off spaces the next permissions further apart.
+	a.Overload()
+	clk.Add(100 * time.Millisecond)
+	ok, retryAfter = a.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	clk.Add(100 * time.Millisecond)
+	ok, _ = a.TryTake()
+	assert.True(t, ok)
+	assert.Equal(t, Rate(5), a.Stats().Rate)
+}
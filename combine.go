@@ -0,0 +1,258 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Combine returns a Limiter that enforces all the given limits at once,
+// such as 10 per second and 500 per minute:
+//
+//	rl, err := ratelimit.Combine(
+//		ratelimit.New(10),
+//		ratelimit.New(500, ratelimit.Per(time.Minute)),
+//	)
+//
+// A permission is issued when all the limiters can issue it, and it's taken
+// from all of them at that time, so waiting for one limiter doesn't waste
+// the permissions of the others. A permission that isn't taken, because
+// TryTake fails or a wait is abandoned, isn't taken from any of them.
+// Waiting callers don't hold a place in line, though: a caller that comes
+// when a permission is issued may get it first. TakeN takes n permissions
+// once all the limiters can issue the first of them, and waits for the
+// rest like a single limiter would, giving them back if the wait is
+// abandoned.
+//
+// Reserve can't wait for the permissions before taking them, so it takes
+// them from every limiter as soon as that limiter can issue them, which
+// lets Reservation.Cancel give them back.
+//
+// The limiters must have been returned by New, NewWithRate, NewUnlimited,
+// NewSlidingWindowLog, NewSlidingWindowCounter, KeyedLimiter.Get or
+// Combine; the other limiters of this package, such as AIMDLimiter, can't
+// be combined. They should share the same Clock; the one of the first
+// limiter is used. The returned
+// Limiter implements ContextLimiter, TryLimiter, WeightedLimiter and
+// ReservingLimiter. The given limiters may still be used on their own.
+func Combine(limiters ...Limiter) (Limiter, error) {
+	var (
+		members []reserver
+		clock   Clock
+	)
+	for _, l := range limiters {
+		switch l := l.(type) {
+		case unlimited:
+			continue
+		case denyAll:
+			return l, nil
+		case *combined:
+			members = append(members, l.members...)
+		case reserver:
+			members = append(members, l)
+		default:
+			return nil, fmt.Errorf("ratelimit: can't combine %T", l)
+		}
+		if clock == nil {
+			clock = clockOf(l)
+		}
+	}
+
+	if len(members) == 0 {
+		return unlimited{}, nil
+	}
+	return &combined{members: members, clock: clock}, nil
+}
+
+// clockOf returns the clock of a limiter returned by this package.
+func clockOf(l Limiter) Clock {
+	switch l := l.(type) {
+	case *atomicInt64Limiter:
+		return l.clock
+	case *atomicLimiter:
+		return l.clock
+	case *mutexLimiter:
+		return l.clock
+	case *slidingLogLimiter:
+		return l.clock
+	case *slidingCounterLimiter:
+		return l.clock
+	case *combined:
+		return l.clock
+	}
+	panic(fmt.Sprintf("ratelimit: no clock for %T", l))
+}
+
+// combined is a Limiter that enforces the limits of all its members.
+type combined struct {
+	members []reserver
+	clock   Clock
+
+	// mu serializes reservations, so that concurrent callers don't keep
+	// invalidating each other's view of the members.
+	mu sync.Mutex
+}
+
+// Take blocks until all the limiters issue a permission.
+func (c *combined) Take() time.Time {
+	t, _ := c.take(context.Background(), 1)
+	return t
+}
+
+// TakeContext is like Take, but can be cancelled through ctx.
+func (c *combined) TakeContext(ctx context.Context) (time.Time, error) {
+	return c.take(ctx, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (c *combined) TryTake() (bool, time.Duration) {
+	return tryTake(c.clock, c, 1)
+}
+
+// TakeN is like Take, for n permissions.
+func (c *combined) TakeN(n int) time.Time {
+	t, _ := c.take(context.Background(), n)
+	return t
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (c *combined) TryTakeN(n int) (bool, time.Duration) {
+	return tryTake(c.clock, c, n)
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (c *combined) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	return c.take(ctx, n)
+}
+
+// Reserve takes n permissions without waiting for them.
+func (c *combined) Reserve(n int) *Reservation {
+	return newReservation(c.clock, c, n)
+}
+
+// take waits until all the members can issue the first of n permissions
+// right away, and only then takes all of them, waiting for the rest as a
+// single limiter would. Nothing is taken if ctx is done before the first
+// permission, and the permissions are given back if it's done after.
+func (c *combined) take(ctx context.Context, n int) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	start := c.clock.Now()
+	if n < 1 {
+		return start, nil
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	for {
+		now := c.clock.Now()
+		if hasDeadline && peek(c, now, n).After(deadline) {
+			return time.Time{}, ErrWaitExceedsDeadline
+		}
+		first := peek(c, now, 1)
+		if first.After(now) {
+			// The members may have moved by the time the wait is over, if
+			// they're used on their own or by other callers.
+			if err := sleepContext(ctx, c.clock, first.Sub(now)); err != nil {
+				return time.Time{}, err
+			}
+			continue
+		}
+
+		maxWait := time.Duration(math.MaxInt64)
+		if hasDeadline {
+			maxWait = deadline.Sub(now)
+		}
+		issuedAt, ok := c.reserve(now, n, maxWait)
+		if !ok {
+			return time.Time{}, waitForever(ctx)
+		}
+		if err := sleepContext(ctx, c.clock, issuedAt.Sub(now)); err != nil {
+			c.refund(n)
+			return time.Time{}, err
+		}
+		c.record(n, issuedAt.Sub(start))
+		return issuedAt, nil
+	}
+}
+
+func (c *combined) reserve(now time.Time, n int, maxWait time.Duration) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The permissions are issued once the slowest member issues them.
+	issuedAt := now
+	for _, m := range c.members {
+		if t := peek(m, now, n); t.After(issuedAt) {
+			issuedAt = t
+		}
+	}
+	// Like the members, waits of maxPerRequest or longer are never taken.
+	if wait := issuedAt.Sub(now); wait > maxWait || wait >= maxPerRequest {
+		return issuedAt, false
+	}
+
+	// Every member is charged at now, as if it was used on its own, so
+	// that a refund gives it back exactly what it took.
+	for i, m := range c.members {
+		t, ok := m.reserve(now, n, maxWait)
+		if t.After(issuedAt) {
+			issuedAt = t
+		}
+		if !ok {
+			// The member was used on its own in the meantime, or could
+			// only tell how late the permissions are at least.
+			for _, m := range c.members[:i] {
+				m.refund(n)
+			}
+			return issuedAt, false
+		}
+	}
+	return issuedAt, true
+}
+
+func (c *combined) refund(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, m := range c.members {
+		m.refund(n)
+	}
+}
+
+func (c *combined) record(n int, d time.Duration) {
+	for _, m := range c.members {
+		m.record(n, d)
+	}
+}
+
+// peek returns the time r would issue n permissions requested at now,
+// without taking them. Limiters that can't tell it cheaply may return an
+// earlier time, before which the permissions can't be issued.
+func peek(r reserver, now time.Time, n int) time.Time {
+	// No wait is short enough for a negative maxWait.
+	issuedAt, _ := r.reserve(now, n, -1)
+	return issuedAt
+}
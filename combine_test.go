@@ -0,0 +1,243 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCombined combines a limiter of 10 per second with one of 1 per second,
+// neither of them with slack.
+func newCombined(t *testing.T, r testRunner) (c, fast, slow Limiter) {
+	fast = r.createLimiter(10, WithoutSlack)
+	slow = r.createLimiter(1, WithoutSlack)
+	c, err := Combine(fast, slow)
+	require.NoError(t, err)
+	return c, fast, slow
+}
+
+func TestCombineTryTake(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		c, fast, _ := newCombined(t, r)
+		clk := r.getClock()
+
+		ok, _ := c.(TryLimiter).TryTake()
+		assert.True(t, ok)
+		ok, retryAfter := c.(TryLimiter).TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, time.Second, retryAfter, "should wait for the slowest limiter")
+
+		// The failed TryTake took nothing from the fast limiter.
+		clk.Add(100 * time.Millisecond)
+		ok, _ = fast.(TryLimiter).TryTake()
+		assert.True(t, ok)
+	})
+}
+
+func TestCombineTake(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		c, fast, _ := newCombined(t, r)
+		clk := r.getClock()
+		start := c.Take()
+
+		results := make(chan time.Time)
+		var startWg sync.WaitGroup
+		startWg.Add(1)
+		go func() {
+			startWg.Done()
+			results <- c.Take()
+		}()
+
+		startWg.Wait()
+		clk.Add(time.Second)
+		assert.Equal(t, start.Add(time.Second), <-results)
+
+		// The fast limiter was charged when the permission was issued,
+		// not when the slow one started to wait for it.
+		ok, retryAfter := fast.(TryLimiter).TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 100*time.Millisecond, retryAfter)
+	})
+}
+
+func TestCombineTakeContextDeadline(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		c, fast, _ := newCombined(t, r)
+		clk := r.getClock()
+		c.Take()
+
+		ctx, cancel := clk.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		_, err := c.(ContextLimiter).TakeContext(ctx)
+		assert.Equal(t, ErrWaitExceedsDeadline, err)
+
+		clk.Add(100 * time.Millisecond)
+		ok, _ := fast.(TryLimiter).TryTake()
+		assert.True(t, ok, "nothing should be taken from the fast limiter")
+	})
+}
+
+func TestCombineTakeContextCancel(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		fast := r.createLimiter(10)
+		slow := r.createLimiter(1, Per(time.Minute))
+		c, err := Combine(fast, slow)
+		require.NoError(t, err)
+		clk := r.getClock()
+		c.Take()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error)
+		go func() {
+			_, err := c.(ContextLimiter).TakeContext(ctx)
+			errs <- err
+		}()
+		clk.Add(time.Second)
+		cancel()
+		assert.Equal(t, context.Canceled, <-errs)
+
+		// The fast limiter on its own isn't held back by the wait that
+		// was abandoned.
+		for i := 0; i < 10; i++ {
+			ok, _ := fast.(TryLimiter).TryTake()
+			assert.True(t, ok, "take %d should be allowed", i)
+		}
+	})
+}
+
+func TestCombineReserveCancelIdle(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		fast := r.createLimiter(10)
+		slow := r.createLimiter(1, Per(time.Minute))
+		c, err := Combine(fast, slow)
+		require.NoError(t, err)
+		c.Take()
+		r.getClock().Add(time.Second)
+		available := fast.(StatsLimiter).Stats().Available
+
+		res := c.(ReservingLimiter).Reserve(1)
+		assert.Equal(t, 59*time.Second, res.Delay())
+		res.Cancel()
+		assert.Equal(t, available, fast.(StatsLimiter).Stats().Available,
+			"should give the fast limiter back what it took")
+	})
+}
+
+func TestCombineReserveCancel(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		c, fast, slow := newCombined(t, r)
+		clk := r.getClock()
+		c.Take()
+
+		res := c.(ReservingLimiter).Reserve(1)
+		assert.Equal(t, time.Second, res.Delay())
+		res.Cancel()
+
+		clk.Add(time.Second)
+		for _, l := range []Limiter{fast, slow} {
+			ok, _ := l.(TryLimiter).TryTake()
+			assert.True(t, ok, "should give the permissions back to all limiters")
+		}
+	})
+}
+
+func TestCombineTakeN(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		fast := r.createLimiter(10, WithoutSlack)
+		slow := r.createLimiter(5, WithoutSlack)
+		c, err := Combine(fast, slow)
+		require.NoError(t, err)
+		clk := r.getClock()
+		start := clk.Now()
+
+		ok, retryAfter := c.(WeightedLimiter).TryTakeN(3)
+		assert.False(t, ok)
+		assert.Equal(t, 400*time.Millisecond, retryAfter)
+
+		c, err = Combine(c, r.createLimiter(1, WithoutSlack))
+		require.NoError(t, err)
+		ok, retryAfter = c.(WeightedLimiter).TryTakeN(2)
+		assert.False(t, ok)
+		assert.Equal(t, time.Second, retryAfter, "should flatten combined limiters")
+		assert.True(t, clk.Now().Equal(start))
+	})
+}
+
+func TestCombineTakeNBurst(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		c, fast, _ := newCombined(t, r)
+		clk := r.getClock()
+		start := clk.Now()
+
+		// More permissions than any of the limiters can issue at once.
+		results := make(chan time.Time)
+		var startWg sync.WaitGroup
+		startWg.Add(1)
+		go func() {
+			startWg.Done()
+			results <- c.(WeightedLimiter).TakeN(3)
+		}()
+		startWg.Wait()
+		clk.Add(2 * time.Second)
+		assert.Equal(t, start.Add(2*time.Second), <-results)
+
+		ctx, cancel := clk.WithTimeout(context.Background(), 1500*time.Millisecond)
+		defer cancel()
+		_, err := c.(WeightedLimiter).TakeNContext(ctx, 2)
+		assert.Equal(t, ErrWaitExceedsDeadline, err)
+		ok, _ := fast.(TryLimiter).TryTake()
+		assert.True(t, ok, "nothing should be taken from the fast limiter")
+
+		ctx, cancel = clk.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		errs := make(chan error)
+		startWg.Add(1)
+		go func() {
+			startWg.Done()
+			ts, err := c.(WeightedLimiter).TakeNContext(ctx, 2)
+			results <- ts
+			errs <- err
+		}()
+		startWg.Wait()
+		clk.Add(time.Second)
+		clk.Add(time.Second)
+		assert.Equal(t, start.Add(4*time.Second), <-results)
+		assert.NoError(t, <-errs)
+	})
+}
+
+func TestCombineSpecial(t *testing.T) {
+	t.Parallel()
+
+	c, err := Combine()
+	require.NoError(t, err)
+	assert.Equal(t, unlimited{}, c)
+
+	c, err = Combine(NewUnlimited(), New(0), New(10))
+	require.NoError(t, err)
+	assert.Equal(t, denyAll{}, c)
+
+	l := New(10)
+	c, err = Combine(NewUnlimited(), l)
+	require.NoError(t, err)
+	assert.Implements(t, (*ReservingLimiter)(nil), c)
+
+	_, err = Combine(l, struct{ Limiter }{l})
+	assert.EqualError(t, err, "ratelimit: can't combine struct { ratelimit.Limiter }")
+	aimd, err := NewAIMD(10, 1, 100)
+	require.NoError(t, err)
+	_, err = Combine(l, aimd)
+	assert.EqualError(t, err, "ratelimit: can't combine *ratelimit.AIMDLimiter")
+}
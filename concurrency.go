@@ -0,0 +1,268 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ConcurrencyLimiter limits the number of operations in flight, which
+// protects slow dependencies better than a rate does: every operation
+// acquires a slot before it starts, and releases it once it's done.
+//
+// Callers waiting for a slot get it in the order they asked for it.
+type ConcurrencyLimiter struct {
+	mu       sync.Mutex
+	max      int
+	inFlight int
+	// waiters holds a channel per waiting caller, oldest first, which is
+	// closed to hand a released slot over.
+	waiters list.List
+
+	*counters
+	clock Clock
+}
+
+// NewConcurrency returns a ConcurrencyLimiter that allows at most
+// maxInFlight operations at once. The Clock is used to measure waits,
+// which are counted WithStats.
+func NewConcurrency(maxInFlight int, opts ...Option) (*ConcurrencyLimiter, error) {
+	if err := checkMaxInFlight(maxInFlight); err != nil {
+		return nil, err
+	}
+	config := buildConfig(opts)
+	return newConcurrency(maxInFlight, config.clock, config.counters()), nil
+}
+
+func newConcurrency(maxInFlight int, clock Clock, c *counters) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		max:      maxInFlight,
+		counters: c,
+		clock:    clock,
+	}
+}
+
+func checkMaxInFlight(maxInFlight int) error {
+	if maxInFlight < 1 {
+		return fmt.Errorf("ratelimit: max in flight must be positive, got %d", maxInFlight)
+	}
+	return nil
+}
+
+// Acquire blocks until a slot is free, or returns the error of ctx if it's
+// done first. Every successful Acquire must be followed by a Release.
+func (c *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.inFlight < c.max && c.waiters.Len() == 0 {
+		c.inFlight++
+		c.mu.Unlock()
+		c.record(1, 0)
+		return nil
+	}
+	ready := make(chan struct{})
+	waiter := c.waiters.PushBack(ready)
+	c.mu.Unlock()
+
+	start := c.clock.Now()
+	select {
+	case <-ready:
+		c.record(1, c.clock.Now().Sub(start))
+		return nil
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	select {
+	case <-ready:
+		// The slot was handed over before we gave up, so it goes to the
+		// next caller instead.
+		c.mu.Unlock()
+		c.Release()
+	default:
+		c.waiters.Remove(waiter)
+		c.mu.Unlock()
+	}
+	return ctx.Err()
+}
+
+// TryAcquire acquires a slot only if one is free, without waiting.
+func (c *ConcurrencyLimiter) TryAcquire() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inFlight >= c.max || c.waiters.Len() > 0 {
+		return false
+	}
+	c.inFlight++
+	c.record(1, 0)
+	return true
+}
+
+// Release frees a slot taken by Acquire or TryAcquire. It panics if no
+// slot is taken.
+func (c *ConcurrencyLimiter) Release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inFlight == 0 {
+		panic("ratelimit: Release without Acquire")
+	}
+	if front := c.waiters.Front(); front != nil && c.inFlight <= c.max {
+		// The slot stays in flight, for the oldest waiter.
+		c.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+	c.inFlight--
+}
+
+// setMax changes the number of operations allowed at once. Operations in
+// flight over a lower max keep their slots until they release them.
+func (c *ConcurrencyLimiter) setMax(maxInFlight int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.max = maxInFlight
+	for c.inFlight < c.max && c.waiters.Len() > 0 {
+		front := c.waiters.Front()
+		c.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+		c.inFlight++
+	}
+}
+
+// Stats returns a snapshot of the limiter. It has no rate, and its
+// permissions are the free slots.
+func (c *ConcurrencyLimiter) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Stats{
+		Rate:        Inf,
+		Available:   c.max - c.inFlight,
+		InFlight:    c.inFlight,
+		MaxInFlight: c.max,
+	}
+	if s.Available > 0 {
+		s.NextPermission = c.clock.Now()
+	} else {
+		// Operations may be in flight over a lowered max.
+		s.Available = 0
+	}
+	return c.counters.fill(s)
+}
+
+// Bulkhead protects a dependency with both a rate and a cap on the
+// operations in flight. Every operation acquires a slot, then a permission
+// of the rate, and releases the slot once it's done.
+type Bulkhead struct {
+	rate  limiter
+	slots *ConcurrencyLimiter
+
+	*counters
+	clock Clock
+}
+
+// NewBulkhead returns a Bulkhead that issues permissions at the given
+// rate, like NewWithRate, to at most maxInFlight operations at once.
+// WithStats counts the operations that acquired the Bulkhead, and how
+// long they waited for both the slot and the permission.
+func NewBulkhead(rate Rate, maxInFlight int, opts ...Option) (*Bulkhead, error) {
+	if err := checkMaxInFlight(maxInFlight); err != nil {
+		return nil, err
+	}
+	rl, err := newLimiter(rate, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	config := buildConfig(opts)
+	return &Bulkhead{
+		rate:     rl,
+		slots:    newConcurrency(maxInFlight, config.clock, nil),
+		counters: config.counters(),
+		clock:    config.clock,
+	}, nil
+}
+
+// Acquire blocks until both a slot and a permission are available, or
+// returns an error like TakeContext does. The slot is only kept if
+// Acquire succeeds, in which case it must be followed by a Release.
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	start := b.clock.Now()
+	if err := b.slots.Acquire(ctx); err != nil {
+		return err
+	}
+	// The slot is acquired first, so that the permission isn't wasted
+	// waiting for it.
+	if _, err := b.rate.TakeContext(ctx); err != nil {
+		b.slots.Release()
+		return err
+	}
+	b.record(1, b.clock.Now().Sub(start))
+	return nil
+}
+
+// TryAcquire acquires a slot and a permission only if both are available
+// without waiting. Otherwise, it returns false and how long until the
+// next permission is issued, which is zero if it's the slot that's
+// missing, as that depends on Release.
+func (b *Bulkhead) TryAcquire() (ok bool, retryAfter time.Duration) {
+	if !b.slots.TryAcquire() {
+		return false, 0
+	}
+	if ok, retryAfter := b.rate.TryTake(); !ok {
+		b.slots.Release()
+		return false, retryAfter
+	}
+	b.record(1, 0)
+	return true, 0
+}
+
+// Release frees the slot taken by Acquire or TryAcquire. It panics if no
+// slot is taken.
+func (b *Bulkhead) Release() {
+	b.slots.Release()
+}
+
+// Stats returns a snapshot of the Bulkhead: the settings and state of its
+// rate, along with its slots.
+func (b *Bulkhead) Stats() Stats {
+	s := b.rate.Stats()
+	slots := b.slots.Stats()
+	s.InFlight, s.MaxInFlight = slots.InFlight, slots.MaxInFlight
+	if slots.Available < s.Available {
+		s.Available = slots.Available
+	}
+	if slots.Available == 0 {
+		// The next permission waits for a Release.
+		s.NextPermission = time.Time{}
+	}
+	return b.counters.fill(s)
+}
@@ -0,0 +1,206 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForWaiters waits until n callers wait for a slot of c.
+func waitForWaiters(t *testing.T, c *ConcurrencyLimiter, n int) {
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.waiters.Len() == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestNewConcurrencyInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewConcurrency(0)
+	assert.EqualError(t, err, "ratelimit: max in flight must be positive, got 0")
+	_, err = NewBulkhead(10, -1)
+	assert.EqualError(t, err, "ratelimit: max in flight must be positive, got -1")
+	_, err = NewBulkhead(-1, 1)
+	assert.EqualError(t, err, "ratelimit: rate must not be negative, got -1")
+}
+
+func TestConcurrency(t *testing.T) {
+	t.Parallel()
+	c, err := NewConcurrency(2)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Acquire(context.Background()))
+	assert.True(t, c.TryAcquire())
+	assert.False(t, c.TryAcquire(), "all slots are taken")
+
+	c.Release()
+	assert.True(t, c.TryAcquire(), "released slots should be reused")
+
+	c.Release()
+	c.Release()
+	assert.Panics(t, c.Release)
+}
+
+func TestConcurrencyWait(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	c, err := NewConcurrency(1, WithClock(clk), WithStats())
+	require.NoError(t, err)
+	require.True(t, c.TryAcquire())
+
+	// Waiters get the slot in order.
+	order := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		i := i
+		go func() {
+			assert.NoError(t, c.Acquire(context.Background()))
+			order <- i
+		}()
+		waitForWaiters(t, c, i+1)
+	}
+	assert.False(t, c.TryAcquire(), "shouldn't jump the queue")
+
+	clk.Add(time.Second)
+	c.Release()
+	assert.Equal(t, 0, <-order)
+	c.Release()
+	assert.Equal(t, 1, <-order)
+
+	s := c.Stats()
+	assert.Equal(t, 1, s.InFlight)
+	assert.Equal(t, 1, s.MaxInFlight)
+	assert.Zero(t, s.Available)
+	assert.True(t, s.NextPermission.IsZero(), "the next permission depends on Release")
+	assert.Equal(t, int64(3), s.Takes)
+	assert.Equal(t, int64(2), s.Throttled)
+	assert.Equal(t, 2*time.Second, s.Waited)
+}
+
+func TestConcurrencyCancel(t *testing.T) {
+	t.Parallel()
+	c, err := NewConcurrency(1)
+	require.NoError(t, err)
+	require.True(t, c.TryAcquire())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.Acquire(ctx)
+	}()
+	waitForWaiters(t, c, 1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	waitForWaiters(t, c, 0)
+	c.Release()
+	assert.True(t, c.TryAcquire(), "abandoned waits shouldn't hold slots")
+
+	assert.Equal(t, context.Canceled, c.Acquire(ctx))
+}
+
+func TestConcurrencyStats(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	c, err := NewConcurrency(3, WithClock(clk))
+	require.NoError(t, err)
+	require.True(t, c.TryAcquire())
+
+	assert.Equal(t, Stats{
+		Rate:           Inf,
+		Available:      2,
+		NextPermission: clk.Now(),
+		InFlight:       1,
+		MaxInFlight:    3,
+	}, c.Stats())
+}
+
+func TestBulkhead(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	b, err := NewBulkhead(10, 2, WithoutSlack, WithClock(clk), WithStats())
+	require.NoError(t, err)
+
+	ok, _ := b.TryAcquire()
+	assert.True(t, ok)
+	ok, retryAfter := b.TryAcquire()
+	assert.False(t, ok, "limited by the rate")
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	clk.Add(100 * time.Millisecond)
+	ok, _ = b.TryAcquire()
+	assert.True(t, ok)
+
+	clk.Add(100 * time.Millisecond)
+	ok, retryAfter = b.TryAcquire()
+	assert.False(t, ok, "limited by the slots")
+	assert.Zero(t, retryAfter)
+	s := b.Stats()
+	assert.Equal(t, 2, s.InFlight)
+	assert.Zero(t, s.Available)
+	assert.True(t, s.NextPermission.IsZero())
+
+	// The failed TryAcquire took no permission.
+	b.Release()
+	ok, _ = b.TryAcquire()
+	assert.True(t, ok)
+
+	s = b.Stats()
+	assert.Equal(t, Rate(10), s.Rate)
+	assert.Equal(t, 2, s.MaxInFlight)
+	assert.Equal(t, int64(3), s.Takes)
+}
+
+func TestBulkheadAcquire(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	b, err := NewBulkhead(10, 1, WithoutSlack, WithClock(clk), WithStats())
+	require.NoError(t, err)
+	require.NoError(t, b.Acquire(context.Background()))
+
+	done := make(chan error)
+	go func() {
+		done <- b.Acquire(context.Background())
+	}()
+	waitForWaiters(t, b.slots, 1)
+
+	// The waiter gets the slot, then waits for the rate.
+	clk.Add(50 * time.Millisecond)
+	b.Release()
+	select {
+	case err := <-done:
+		t.Fatalf("Acquire returned before the next permission: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	for {
+		clk.Add(10 * time.Millisecond)
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+			s := b.Stats()
+			assert.Equal(t, int64(2), s.Takes)
+			assert.Equal(t, int64(1), s.Throttled)
+			assert.Equal(t, 100*time.Millisecond, s.Waited)
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestBulkheadDeadline(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	b, err := NewBulkhead(1, 1, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+	ok, _ := b.TryAcquire()
+	require.True(t, ok)
+	b.Release()
+
+	ctx, cancel := context.WithDeadline(context.Background(), clk.Now().Add(500*time.Millisecond))
+	defer cancel()
+	assert.Equal(t, ErrWaitExceedsDeadline, b.Acquire(ctx))
+	assert.Zero(t, b.Stats().InFlight, "the slot should be released")
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// dispatcher issues the permissions of a limiter to waiting callers in the
+// order picked by its owner, instead of letting them race for permissions.
+// The owner keeps the waiters in its own queues, guarded by mu.
+type dispatcher struct {
+	rate  *atomicInt64Limiter
+	clock Clock
+
+	mu          sync.Mutex
+	waiting     int
+	dispatching bool
+	// next removes the waiter that gets the next permission from the
+	// queues, or returns nil if there is none. It's called with mu held.
+	next func() *waiter
+	// drained is called with mu held once no caller is waiting, if set.
+	drained func()
+}
+
+type waiter struct {
+	ready    chan struct{}
+	issuedAt time.Time
+}
+
+func newDispatcher(rate Rate, config config, opts []Option) dispatcher {
+	return dispatcher{
+		rate:  newAtomicInt64Based(rate, opts...),
+		clock: config.clock,
+	}
+}
+
+// take blocks until a permission is issued to the caller, or returns the
+// error of ctx if it's done first. If other callers are waiting, the
+// caller waits too: enqueue adds it to the queues, with mu held, and
+// returns a function that removes it from them if it gives up.
+func (d *dispatcher) take(ctx context.Context, enqueue func(*waiter) (remove func())) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	start := d.clock.Now()
+	d.mu.Lock()
+	if d.waiting == 0 {
+		// Nobody is ahead of the caller.
+		if _, ok := d.rate.reserve(start, 1, 0); ok {
+			d.mu.Unlock()
+			d.rate.record(1, 0)
+			return start, nil
+		}
+	}
+	w := &waiter{ready: make(chan struct{})}
+	remove := enqueue(w)
+	d.waiting++
+	if !d.dispatching {
+		d.dispatching = true
+		go d.dispatch()
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		d.rate.record(1, d.clock.Now().Sub(start))
+		return w.issuedAt, nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	select {
+	case <-w.ready:
+		// The permission was issued before we gave up, so it goes back to
+		// the limiter.
+		d.rate.refund(1)
+	default:
+		remove()
+		d.waiting--
+		d.checkDrained()
+	}
+	return time.Time{}, ctx.Err()
+}
+
+// tryTake takes a permission only if nobody waits and it's available.
+func (d *dispatcher) tryTake() (bool, time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clock.Now()
+	if d.waiting > 0 {
+		// The next permissions go to the callers waiting.
+		return false, peek(d.rate, now, d.waiting).Sub(now)
+	}
+	issuedAt, ok := d.rate.reserve(now, 1, 0)
+	if !ok {
+		return false, issuedAt.Sub(now)
+	}
+	d.rate.record(1, 0)
+	return true, 0
+}
+
+// dispatch issues permissions to the waiting callers, one after another,
+// until none is left.
+func (d *dispatcher) dispatch() {
+	for {
+		now := d.clock.Now()
+		issuedAt, _ := d.rate.reserve(now, 1, math.MaxInt64)
+		if wait := issuedAt.Sub(now); wait > 0 {
+			d.clock.Sleep(wait)
+		}
+
+		// The permission goes to whoever is first in line once it's
+		// issued, not when it was reserved.
+		d.mu.Lock()
+		w := d.next()
+		if w == nil {
+			// Everybody gave up in the meantime.
+			d.rate.refund(1)
+		} else {
+			w.issuedAt = issuedAt
+			close(w.ready)
+			d.waiting--
+			d.checkDrained()
+		}
+		if d.waiting == 0 {
+			d.dispatching = false
+			d.mu.Unlock()
+			return
+		}
+		d.mu.Unlock()
+	}
+}
+
+// checkDrained calls drained if no caller is waiting. It must be called
+// with mu held.
+func (d *dispatcher) checkDrained() {
+	if d.waiting == 0 && d.drained != nil {
+		d.drained()
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store holds the state of distributed limiters, so that processes sharing
+// a Store share their limits.
+//
+// The state of a limiter is the time its next permission is issued, in
+// unix nanoseconds, like the state of the limiters returned by New.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the state of the limiter with the given key, or zero if
+	// there is none.
+	Get(ctx context.Context, key string) (int64, error)
+	// CompareAndSwap sets the state of the key to new if it's old, where
+	// zero stands for no state, and reports whether it did. The state may
+	// be dropped once ttl has passed, as it doesn't limit anything after
+	// that.
+	CompareAndSwap(ctx context.Context, key string, old, new int64, ttl time.Duration) (bool, error)
+}
+
+// DistributedLimiter is a Limiter whose state is held by a Store, to limit
+// several processes together. The processes should use the same rate and
+// options for the same key, and their clocks should be in sync.
+//
+// Changes to the limits of a DistributedLimiter only apply to the process
+// that makes them.
+//
+// Methods that can't return an error fail open: if the Store fails, they
+// issue the permissions without waiting. Use TakeContext or TakeNContext
+// to handle the errors of the Store.
+type DistributedLimiter struct {
+	state Store
+	key   string
+
+	atomicLimits
+	*counters
+	clock Clock
+}
+
+// NewDistributed returns a DistributedLimiter that limits to the given rate
+// across all the processes using the same key of the store.
+func NewDistributed(store Store, key string, rate Rate, opts ...Option) (*DistributedLimiter, error) {
+	config := buildConfig(opts)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkRate(rate); err != nil {
+		return nil, err
+	}
+	if rate >= Inf {
+		return nil, errors.New("ratelimit: rate of a distributed limiter must be finite")
+	}
+	if err := checkRateLow(rate, config.per); err != nil {
+		return nil, err
+	}
+
+	l := &DistributedLimiter{
+		state:    store,
+		key:      key,
+		counters: config.counters(),
+		clock:    config.clock,
+	}
+	l.store(config.limits(rate))
+	return l, nil
+}
+
+// Take blocks to ensure that the time spent between multiple Take calls
+// across all processes is on average per/rate.
+func (d *DistributedLimiter) Take() time.Time {
+	return d.TakeN(1)
+}
+
+// TakeContext is like Take, but can be cancelled through ctx, and returns
+// the errors of the Store.
+func (d *DistributedLimiter) TakeContext(ctx context.Context) (time.Time, error) {
+	return d.TakeNContext(ctx, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (d *DistributedLimiter) TryTake() (bool, time.Duration) {
+	return d.TryTakeN(1)
+}
+
+// TakeN is like Take, for n permissions.
+func (d *DistributedLimiter) TakeN(n int) time.Time {
+	now := d.clock.Now()
+	if n < 1 {
+		return now
+	}
+
+	issuedAt, _, err := d.reserveContext(context.Background(), now, n, math.MaxInt64)
+	if err != nil {
+		return now
+	}
+	wait := issuedAt.Sub(now)
+	if wait > 0 {
+		d.clock.Sleep(wait)
+	}
+	d.record(n, wait)
+	return issuedAt
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (d *DistributedLimiter) TryTakeN(n int) (bool, time.Duration) {
+	if n < 1 {
+		return true, 0
+	}
+
+	now := d.clock.Now()
+	issuedAt, ok, err := d.reserveContext(context.Background(), now, n, 0)
+	if err != nil {
+		return true, 0
+	}
+	if !ok {
+		return false, issuedAt.Sub(now)
+	}
+	d.record(n, 0)
+	return true, 0
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (d *DistributedLimiter) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	now := d.clock.Now()
+	if n < 1 {
+		return now, nil
+	}
+
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = deadline.Sub(now)
+	}
+
+	issuedAt, ok, err := d.reserveContext(ctx, now, n, maxWait)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		return time.Time{}, waitForever(ctx)
+	}
+	wait := issuedAt.Sub(now)
+	if err := sleepContext(ctx, d.clock, wait); err != nil {
+		// Giving the permissions back is best effort, as ctx is done.
+		_ = d.refundContext(context.Background(), n)
+		return time.Time{}, err
+	}
+	d.record(n, wait)
+	return issuedAt, nil
+}
+
+// reserveContext is like reserver.reserve, with the state held by the
+// Store.
+func (d *DistributedLimiter) reserveContext(ctx context.Context, now time.Time, n int, maxWait time.Duration) (time.Time, bool, error) {
+	nowNanos := now.UnixNano()
+	for {
+		l := d.load()
+		timeOfNextPermissionIssue, err := d.state.Get(ctx, d.key)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		// the same timeline as atomicInt64Limiter
+		newTimeOfNextPermissionIssue := nextPermissionIssue(l, nowNanos, timeOfNextPermissionIssue) +
+			int64(l.tail(n))
+
+		wait := time.Duration(newTimeOfNextPermissionIssue - nowNanos)
+		if wait < 0 {
+			wait = 0
+		}
+		if wait > maxWait || wait >= maxPerRequest {
+			return now.Add(wait), false, nil
+		}
+
+		swapped, err := d.state.CompareAndSwap(ctx, d.key, timeOfNextPermissionIssue, newTimeOfNextPermissionIssue, stateTTL(l, wait))
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if swapped {
+			return now.Add(wait), true, nil
+		}
+	}
+}
+
+// refundContext gives back n reserved permissions that were not used.
+func (d *DistributedLimiter) refundContext(ctx context.Context, n int) error {
+	for {
+		l := d.load()
+		old, err := d.state.Get(ctx, d.key)
+		if err != nil {
+			return err
+		}
+		if old == 0 {
+			// The state was dropped, so there's nothing to give back to.
+			return nil
+		}
+		// Moving the time of the last issued permission back lets
+		// the next callers have the permissions that were given back.
+		new := old - int64(n)*int64(l.perRequest)
+		wait := time.Duration(new - d.clock.Now().UnixNano())
+		if wait < 0 {
+			wait = 0
+		}
+		swapped, err := d.state.CompareAndSwap(ctx, d.key, old, new, stateTTL(l, wait))
+		if err != nil || swapped {
+			return err
+		}
+	}
+}
+
+// stateTTL returns how long the state must be kept after a permission
+// issued wait from now: until the limiter has accumulated all of its slack.
+func stateTTL(l *limits, wait time.Duration) time.Duration {
+	return wait + l.maxSlack + l.perRequest
+}
+
+// MemoryStore is a Store that holds the state of limiters in memory. It
+// can only share limits within a process, which makes it useful for tests.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]int64
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]int64)}
+}
+
+// Get returns the state of the key.
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.states[key], nil
+}
+
+// CompareAndSwap sets the state of the key to new if it's old. States are
+// never dropped.
+func (s *MemoryStore) CompareAndSwap(_ context.Context, key string, old, new int64, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.states[key] != old {
+		return false, nil
+	}
+	s.states[key] = new
+	return true, nil
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore is a Store that is down.
+type failingStore struct{}
+
+var errStoreDown = errors.New("store is down")
+
+func (failingStore) Get(context.Context, string) (int64, error) {
+	return 0, errStoreDown
+}
+
+func (failingStore) CompareAndSwap(context.Context, string, int64, int64, time.Duration) (bool, error) {
+	return false, errStoreDown
+}
+
+func TestDistributed(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	store := NewMemoryStore()
+
+	newDistributed := func(key string) *DistributedLimiter {
+		d, err := NewDistributed(store, key, 10, WithoutSlack, WithClock(clk))
+		require.NoError(t, err)
+		return d
+	}
+	a, b, other := newDistributed("shared"), newDistributed("shared"), newDistributed("other")
+
+	ok, _ := a.TryTake()
+	assert.True(t, ok)
+	ok, retryAfter := b.TryTake()
+	assert.False(t, ok, "limiters with the same key should share the limit")
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+	ok, _ = other.TryTake()
+	assert.True(t, ok, "limiters with other keys shouldn't")
+
+	clk.Add(100 * time.Millisecond)
+	ok, _ = b.TryTake()
+	assert.True(t, ok)
+	ok, retryAfter = a.TryTakeN(2)
+	assert.False(t, ok)
+	assert.Equal(t, 200*time.Millisecond, retryAfter)
+
+	// Waits longer than can be tracked are never granted.
+	ok, _ = a.TryTakeN(math.MaxInt)
+	assert.False(t, ok)
+	clk.Add(100 * time.Millisecond)
+	ok, _ = a.TryTake()
+	assert.True(t, ok)
+	ok, _ = a.TryTake()
+	assert.False(t, ok)
+}
+
+func TestDistributedTakeContext(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	store := NewMemoryStore()
+	a, err := NewDistributed(store, "key", 10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+	b, err := NewDistributed(store, "key", 10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	start, err := a.TakeContext(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := clk.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = b.TakeContext(ctx)
+	assert.Equal(t, ErrWaitExceedsDeadline, err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := b.TakeContext(ctx)
+		errs <- err
+	}()
+	clk.Add(50 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+
+	// The cancelled permission is given back.
+	clk.Add(50 * time.Millisecond)
+	ts, err := a.TakeContext(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(100*time.Millisecond), ts)
+}
+
+func TestDistributedStoreDown(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	d, err := NewDistributed(failingStore{}, "key", 10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	_, err = d.TakeContext(context.Background())
+	assert.Equal(t, errStoreDown, err)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := d.TryTake()
+		assert.True(t, ok, "should fail open")
+		assert.Equal(t, clk.Now(), d.Take())
+	}
+}
+
+func TestDistributedInvalid(t *testing.T) {
+	t.Parallel()
+	store := NewMemoryStore()
+	tests := []struct {
+		msg     string
+		rate    Rate
+		opts    []Option
+		wantErr string
+	}{
+		{msg: "zero", rate: 0, wantErr: "ratelimit: rate must be positive, got 0"},
+		{msg: "infinite", rate: Inf, wantErr: "ratelimit: rate of a distributed limiter must be finite"},
+		{msg: "option", rate: 1, opts: []Option{WithSlack(-1)}, wantErr: "ratelimit: slack must not be negative, got -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewDistributed(store, "key", tt.rate, tt.opts...)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// FairLimiter shares one rate between tenants, such as the users of a
+// service: when they contend for permissions, each of them gets a share in
+// proportion to its weight, and a tenant alone can use all of the rate.
+//
+// It serves waiting callers with weighted fair queueing: every permission
+// a tenant waits for is tagged with a virtual finish time, which advances
+// by the inverse of the tenant's weight for each of its permissions, and
+// permissions go to the smallest tag first. Tenants that didn't wait
+// start from the current virtual time, so they don't bank permissions
+// while idle.
+type FairLimiter[K comparable] struct {
+	dispatcher
+
+	// weights holds the weights that aren't one.
+	weights map[K]float64
+	// finish is the virtual finish time of the last permission of every
+	// tenant that waited since callers last stopped waiting.
+	finish map[K]float64
+	queue  fairQueue
+	vtime  float64
+	seq    uint64
+}
+
+// NewFair returns a FairLimiter that issues permissions at the given rate,
+// which must be positive and finite, to tenants of weight one unless set
+// otherwise with SetWeight.
+func NewFair[K comparable](rate Rate, opts ...Option) (*FairLimiter[K], error) {
+	config := buildConfig(opts)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkRate(rate); err != nil {
+		return nil, err
+	}
+	if rate >= Inf {
+		return nil, errors.New("ratelimit: rate of a fair limiter must be finite")
+	}
+	if err := checkRateLow(rate, config.per); err != nil {
+		return nil, err
+	}
+
+	f := &FairLimiter[K]{
+		dispatcher: newDispatcher(rate, config, opts),
+		weights:    make(map[K]float64),
+		finish:     make(map[K]float64),
+	}
+	f.next = f.nextWaiter
+	f.drained = f.reset
+	return f, nil
+}
+
+// Take blocks until a permission is issued to the tenant.
+func (f *FairLimiter[K]) Take(tenant K) time.Time {
+	t, _ := f.TakeContext(context.Background(), tenant)
+	return t
+}
+
+// TakeContext is like Take, but returns the error of ctx if it's done
+// before the permission is issued. It can't tell ahead of time whether
+// the wait exceeds the deadline of ctx, as that depends on the tenants
+// that come next.
+func (f *FairLimiter[K]) TakeContext(ctx context.Context, tenant K) (time.Time, error) {
+	return f.take(ctx, func(w *waiter) func() {
+		item := f.enqueue(w, tenant)
+		return func() {
+			heap.Remove(&f.queue, item.index)
+			if f.finish[tenant] == item.finish {
+				// The tenant gets its place back.
+				f.finish[tenant] = item.start
+			}
+		}
+	})
+}
+
+// TryTake takes a permission for the tenant only if no tenant is waiting
+// and it's available without waiting.
+func (f *FairLimiter[K]) TryTake(tenant K) (bool, time.Duration) {
+	return f.tryTake()
+}
+
+// SetWeight sets the weight of the tenant, which must be positive and
+// finite. It applies to the permissions the tenant asks for from then on.
+func (f *FairLimiter[K]) SetWeight(tenant K, weight float64) error {
+	if !(weight > 0 && weight <= math.MaxFloat64) {
+		return fmt.Errorf("ratelimit: weight must be positive and finite, got %v", weight)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if weight == 1 {
+		delete(f.weights, tenant)
+	} else {
+		f.weights[tenant] = weight
+	}
+	return nil
+}
+
+// RemoveWeight sets the weight of the tenant back to one.
+func (f *FairLimiter[K]) RemoveWeight(tenant K) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.weights, tenant)
+}
+
+// Stats returns a snapshot of the limiter.
+func (f *FairLimiter[K]) Stats() Stats {
+	return f.rate.Stats()
+}
+
+// enqueue tags the waiter of the tenant and adds it to the queue. It must
+// be called with the lock held.
+func (f *FairLimiter[K]) enqueue(w *waiter, tenant K) *fairItem {
+	weight, ok := f.weights[tenant]
+	if !ok {
+		weight = 1
+	}
+	start := math.Max(f.vtime, f.finish[tenant])
+	item := &fairItem{
+		waiter: w,
+		start:  start,
+		finish: start + 1/weight,
+		seq:    f.seq,
+	}
+	f.seq++
+	f.finish[tenant] = item.finish
+	heap.Push(&f.queue, item)
+	return item
+}
+
+// nextWaiter removes the waiter with the smallest tag from the queue, if
+// any. It must be called with the lock held.
+func (f *FairLimiter[K]) nextWaiter() *waiter {
+	if len(f.queue) == 0 {
+		return nil
+	}
+	item := heap.Pop(&f.queue).(*fairItem)
+	f.vtime = item.start
+	return item.waiter
+}
+
+// reset forgets the tags once nobody waits, as they only order the
+// callers that wait together. It must be called with the lock held.
+func (f *FairLimiter[K]) reset() {
+	f.finish = make(map[K]float64)
+	f.vtime = 0
+}
+
+type fairItem struct {
+	waiter        *waiter
+	start, finish float64
+	// seq breaks ties between equal tags in the order callers came.
+	seq   uint64
+	index int
+}
+
+// fairQueue is a heap of waiters, smallest tag first.
+type fairQueue []*fairItem
+
+func (q fairQueue) Len() int { return len(q) }
+
+func (q fairQueue) Less(i, j int) bool {
+	if q[i].finish != q[j].finish {
+		return q[i].finish < q[j].finish
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q fairQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *fairQueue) Push(x any) {
+	item := x.(*fairItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *fairQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
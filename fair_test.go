@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fairTakeInOrder starts a caller for each of the tenants, one after
+// another, and returns the channel their tenants are sent to once they
+// get a permission.
+func fairTakeInOrder(t *testing.T, f *FairLimiter[string], tenants ...string) <-chan string {
+	got := make(chan string, len(tenants))
+	for i, tenant := range tenants {
+		tenant := tenant
+		go func() {
+			_, err := f.TakeContext(context.Background(), tenant)
+			assert.NoError(t, err)
+			got <- tenant
+		}()
+		require.Eventually(t, func() bool {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.waiting == i+1
+		}, time.Second, time.Millisecond)
+	}
+	return got
+}
+
+func TestNewFairInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewFair[string](0)
+	assert.EqualError(t, err, "ratelimit: rate must be positive, got 0")
+	_, err = NewFair[string](Inf)
+	assert.EqualError(t, err, "ratelimit: rate of a fair limiter must be finite")
+	_, err = NewFair[string](10, Per(0))
+	assert.EqualError(t, err, "ratelimit: per must be positive, got 0s")
+}
+
+func TestFair(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	f, err := NewFair[string](10, WithoutSlack, WithClock(clk), WithStats())
+	require.NoError(t, err)
+
+	// A tenant alone uses all of the rate.
+	f.Take("a")
+	ok, retryAfter := f.TryTake("a")
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	// Tenants take turns, even if one of them came with more callers.
+	got := fairTakeInOrder(t, f, "a", "a", "a", "b", "b")
+	ok, _ = f.TryTake("c")
+	assert.False(t, ok, "shouldn't jump the queue")
+	for _, want := range []string{"a", "b", "a", "b", "a"} {
+		assert.Equal(t, want, receiveAdvancing(clk, got))
+	}
+	assert.Equal(t, int64(6), f.Stats().Takes)
+}
+
+func TestFairWeights(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	f, err := NewFair[string](10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+	f.Take("a")
+
+	require.NoError(t, f.SetWeight("b", 3))
+	got := fairTakeInOrder(t, f, "a", "a", "a", "a", "b", "b", "b", "b")
+	for _, want := range []string{"b", "b", "a", "b", "b", "a", "a", "a"} {
+		assert.Equal(t, want, receiveAdvancing(clk, got))
+	}
+
+	f.RemoveWeight("b")
+	got = fairTakeInOrder(t, f, "a", "a", "b", "b")
+	for _, want := range []string{"a", "b", "a", "b"} {
+		assert.Equal(t, want, receiveAdvancing(clk, got))
+	}
+
+	assert.EqualError(t, f.SetWeight("b", 0), "ratelimit: weight must be positive and finite, got 0")
+}
+
+func TestFairCancel(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	f, err := NewFair[string](10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+	f.Take("a")
+
+	got := fairTakeInOrder(t, f, "a", "a")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := f.TakeContext(ctx, "b")
+		done <- err
+	}()
+	require.Eventually(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.waiting == 3
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	for _, want := range []string{"a", "a"} {
+		assert.Equal(t, want, receiveAdvancing(clk, got))
+	}
+}
@@ -0,0 +1,26 @@
+module go.uber.org/ratelimit/grpclimit
+
+go 1.20
+
+// Builds against the root module of this repository. Consumers ignore the
+// replace, and get the version required below, which must be released
+// first.
+replace go.uber.org/ratelimit => ../
+
+require (
+	github.com/benbjohnson/clock v1.3.0
+	github.com/stretchr/testify v1.6.1
+	go.uber.org/ratelimit v0.4.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.33.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
@@ -0,0 +1,225 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package grpclimit provides gRPC server interceptors that rate-limit calls.
+//
+// By default, calls wait for their permission, for as long as their
+// deadline allows:
+//
+//	rl := ratelimit.New(100)
+//	srv := grpc.NewServer(
+//		grpc.UnaryInterceptor(grpclimit.UnaryServerInterceptor(rl)),
+//		grpc.StreamInterceptor(grpclimit.StreamServerInterceptor(rl)),
+//	)
+//
+// Calls whose wait would outlast their deadline fail right away with
+// codes.ResourceExhausted, and a RetryInfo detail telling when to retry.
+// With WithoutWaiting, all calls over the limit fail that way.
+//
+// It's a separate module so that the ratelimit package doesn't depend
+// on gRPC.
+package grpclimit // import "go.uber.org/ratelimit/grpclimit"
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// config configures the interceptors.
+type config struct {
+	wait  bool
+	keyed *ratelimit.KeyedLimiter[string]
+	key   func(ctx context.Context, fullMethod string) string
+}
+
+// Option configures the interceptors.
+type Option interface {
+	apply(*config)
+}
+
+type waitOption bool
+
+func (o waitOption) apply(c *config) {
+	c.wait = bool(o)
+}
+
+// WithoutWaiting configures the interceptors to reject calls that would
+// have to wait for a permission, instead of waiting. It relies on the
+// limiter implementing ratelimit.TryLimiter, as all the limiters of the
+// ratelimit package do; calls wait on other limiters.
+var WithoutWaiting Option = waitOption(false)
+
+type keyOption struct {
+	keyed *ratelimit.KeyedLimiter[string]
+	key   func(context.Context, string) string
+}
+
+func (o keyOption) apply(c *config) {
+	c.keyed = o.keyed
+	c.key = o.key
+}
+
+// WithKey configures the interceptors to limit each key separately, with
+// the limiters held by keyed. The key of a call is extracted with key, for
+// example Method or MetadataKey. Calls whose key is empty are limited by
+// the limiter passed to the interceptor.
+func WithKey(keyed *ratelimit.KeyedLimiter[string], key func(ctx context.Context, fullMethod string) string) Option {
+	return keyOption{keyed: keyed, key: key}
+}
+
+// Method returns the full method name of the call, such as
+// "/grpc.health.v1.Health/Check", to limit each method separately.
+func Method(_ context.Context, fullMethod string) string {
+	return fullMethod
+}
+
+// MetadataKey returns a key extractor that reads the first value of the
+// named metadata of the incoming call, such as a tenant or client ID.
+func MetadataKey(name string) func(context.Context, string) string {
+	return func(ctx context.Context, _ string) string {
+		if v := metadata.ValueFromIncomingContext(ctx, name); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor that limits the unary calls
+// it serves with l. l may be nil if WithKey is used, in which case calls
+// without a key aren't limited.
+func UnaryServerInterceptor(l ratelimit.Limiter, opts ...Option) grpc.UnaryServerInterceptor {
+	i := newInterceptor(l, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.take(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that limits the streams
+// it serves with l, one permission per stream. l may be nil if WithKey is
+// used, in which case streams without a key aren't limited.
+func StreamServerInterceptor(l ratelimit.Limiter, opts ...Option) grpc.StreamServerInterceptor {
+	i := newInterceptor(l, opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.take(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+type interceptor struct {
+	config
+	limiter ratelimit.Limiter
+}
+
+func newInterceptor(l ratelimit.Limiter, opts []Option) *interceptor {
+	c := config{wait: true}
+	for _, opt := range opts {
+		opt.apply(&c)
+	}
+	return &interceptor{config: c, limiter: l}
+}
+
+// take takes a permission for the call, or returns the status error to
+// fail it with.
+func (i *interceptor) take(ctx context.Context, fullMethod string) error {
+	l := i.limiter
+	if i.keyed != nil {
+		if key := i.key(ctx, fullMethod); key != "" {
+			l = i.keyed.Get(key)
+		}
+	}
+	if l == nil {
+		return nil
+	}
+
+	if i.wait {
+		return takeWaiting(ctx, l)
+	}
+	return takeRejecting(l)
+}
+
+func takeWaiting(ctx context.Context, l ratelimit.Limiter) error {
+	cl, ok := l.(ratelimit.ContextLimiter)
+	if !ok {
+		l.Take()
+		return nil
+	}
+
+	_, err := cl.TakeContext(ctx)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ratelimit.ErrWaitExceedsDeadline) {
+		var d time.Duration
+		if rl, ok := l.(ratelimit.ReservingLimiter); ok {
+			d = retryAfter(rl)
+		}
+		return resourceExhausted(d)
+	}
+	// The call was cancelled or its deadline passed while waiting.
+	return status.FromContextError(err).Err()
+}
+
+func takeRejecting(l ratelimit.Limiter) error {
+	tl, ok := l.(ratelimit.TryLimiter)
+	if !ok {
+		l.Take()
+		return nil
+	}
+
+	if ok, retryAfter := tl.TryTake(); !ok {
+		return resourceExhausted(retryAfter)
+	}
+	return nil
+}
+
+// retryAfter returns how long until l issues a permission, on its own
+// clock. The permission reserved to find out is given back.
+func retryAfter(l ratelimit.ReservingLimiter) time.Duration {
+	res := l.Reserve(1)
+	defer res.Cancel()
+	return res.Delay()
+}
+
+// resourceExhausted returns the error of a call rejected by the limiter,
+// with a hint to retry after d.
+func resourceExhausted(d time.Duration) error {
+	if d < 0 {
+		d = 0
+	}
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	if withInfo, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(d)}); err == nil {
+		st = withInfo
+	}
+	return st.Err()
+}
@@ -0,0 +1,176 @@
+package grpclimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newClient serves the health service through the given interceptors,
+// and returns a client for it.
+func newClient(t *testing.T, opts ...grpc.ServerOption) healthpb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func check(ctx context.Context, client healthpb.HealthClient) error {
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+// assertExhausted asserts that err rejects a call, with a hint to retry
+// after about d.
+func assertExhausted(t *testing.T, err error, d time.Duration) {
+	t.Helper()
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code(), "unexpected error: %v", err)
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok, "unexpected detail %T", st.Details()[0])
+	assert.InDelta(t, d, info.RetryDelay.AsDuration(), float64(time.Second))
+}
+
+func TestUnaryWaiting(t *testing.T) {
+	t.Parallel()
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	client := newClient(t, grpc.UnaryInterceptor(UnaryServerInterceptor(rl)))
+
+	require.NoError(t, check(context.Background(), client))
+
+	// The next permission is issued after the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assertExhausted(t, check(ctx, client), time.Minute)
+}
+
+func TestUnaryWaitingClock(t *testing.T) {
+	t.Parallel()
+	// The hint is on the clock of the limiter, not the wall clock, which
+	// is an hour behind.
+	clk := clock.NewMock()
+	clk.Set(time.Now().Add(time.Hour))
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack, ratelimit.WithClock(clk))
+	client := newClient(t, grpc.UnaryInterceptor(UnaryServerInterceptor(rl)))
+	require.NoError(t, check(context.Background(), client))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assertExhausted(t, check(ctx, client), time.Minute)
+}
+
+func TestUnaryWithoutWaiting(t *testing.T) {
+	t.Parallel()
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	client := newClient(t, grpc.UnaryInterceptor(UnaryServerInterceptor(rl, WithoutWaiting)))
+
+	require.NoError(t, check(context.Background(), client))
+	assertExhausted(t, check(context.Background(), client), time.Minute)
+}
+
+func TestUnaryCancelled(t *testing.T) {
+	t.Parallel()
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	i := UnaryServerInterceptor(rl)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Method"}
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+
+	res, err := i(context.Background(), nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	// Without a deadline, the call waits until it's cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = i(ctx, nil, info, handler)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	client := newClient(t, grpc.StreamInterceptor(StreamServerInterceptor(rl, WithoutWaiting)))
+
+	watch := func() error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}
+
+	require.NoError(t, watch())
+	assertExhausted(t, watch(), time.Minute)
+
+	// Unary calls aren't intercepted.
+	require.NoError(t, check(context.Background(), client))
+}
+
+func TestPerMethod(t *testing.T) {
+	t.Parallel()
+	keyed, err := ratelimit.NewKeyed[string](1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	require.NoError(t, err)
+	i := UnaryServerInterceptor(nil, WithoutWaiting, WithKey(keyed, Method))
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	call := func(method string) error {
+		_, err := i(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	require.NoError(t, call("/test/A"))
+	assertExhausted(t, call("/test/A"), time.Minute)
+	require.NoError(t, call("/test/B"))
+	assert.Equal(t, 2, keyed.Len())
+}
+
+func TestPerMetadataKey(t *testing.T) {
+	t.Parallel()
+	keyed, err := ratelimit.NewKeyed[string](1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	require.NoError(t, err)
+	client := newClient(t, grpc.UnaryInterceptor(
+		UnaryServerInterceptor(nil, WithoutWaiting, WithKey(keyed, MetadataKey("tenant"))),
+	))
+	withTenant := func(tenant string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "tenant", tenant)
+	}
+
+	require.NoError(t, check(withTenant("a"), client))
+	assertExhausted(t, check(withTenant("a"), client), time.Minute)
+	require.NoError(t, check(withTenant("b"), client))
+
+	// Calls without the metadata aren't limited, as no limiter was given.
+	for j := 0; j < 3; j++ {
+		require.NoError(t, check(context.Background(), client))
+	}
+}
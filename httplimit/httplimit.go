@@ -0,0 +1,212 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package httplimit provides net/http middleware that rate-limits requests.
+//
+// By default, requests wait for their permission, for as long as their
+// context allows:
+//
+//	rl := ratelimit.New(100)
+//	http.ListenAndServe(":8080", httplimit.Middleware(rl)(handler))
+//
+// With WithoutWaiting, requests over the limit are rejected right away with
+// 429 Too Many Requests and a Retry-After header instead.
+package httplimit // import "go.uber.org/ratelimit/httplimit"
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/ratelimit"
+)
+
+// config configures the middleware.
+type config struct {
+	wait   bool
+	reject http.Handler
+	keyed  *ratelimit.KeyedLimiter[string]
+	key    func(*http.Request) string
+}
+
+// Option configures the middleware.
+type Option interface {
+	apply(*config)
+}
+
+type waitOption bool
+
+func (o waitOption) apply(c *config) {
+	c.wait = bool(o)
+}
+
+// WithoutWaiting configures the middleware to reject requests that would
+// have to wait for a permission, instead of waiting. It relies on the
+// limiter implementing ratelimit.TryLimiter, as all the limiters of the
+// ratelimit package do; requests wait on other limiters.
+var WithoutWaiting Option = waitOption(false)
+
+type rejectOption struct {
+	h http.Handler
+}
+
+func (o rejectOption) apply(c *config) {
+	c.reject = o.h
+}
+
+// WithRejectHandler configures the handler that responds to rejected
+// requests. The Retry-After header is set before it is called, if known.
+// The default handler responds with 429 Too Many Requests.
+func WithRejectHandler(h http.Handler) Option {
+	return rejectOption{h: h}
+}
+
+type keyOption struct {
+	keyed *ratelimit.KeyedLimiter[string]
+	key   func(*http.Request) string
+}
+
+func (o keyOption) apply(c *config) {
+	c.keyed = o.keyed
+	c.key = o.key
+}
+
+// WithKey configures the middleware to limit each key separately, with the
+// limiters held by keyed. The key of a request is extracted with key, for
+// example RemoteIP. Requests whose key is empty are limited by the limiter
+// passed to Middleware.
+func WithKey(keyed *ratelimit.KeyedLimiter[string], key func(*http.Request) string) Option {
+	return keyOption{keyed: keyed, key: key}
+}
+
+// RemoteIP returns the IP address of the client that sent the request,
+// without the port. It doesn't look at headers set by proxies.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware returns middleware that limits the requests it serves with l.
+// l may be nil if WithKey is used, in which case requests without a key
+// aren't limited.
+func Middleware(l ratelimit.Limiter, opts ...Option) func(http.Handler) http.Handler {
+	c := config{
+		wait:   true,
+		reject: http.HandlerFunc(tooManyRequests),
+	}
+	for _, opt := range opts {
+		opt.apply(&c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &handler{config: c, limiter: l, next: next}
+	}
+}
+
+type handler struct {
+	config
+	limiter ratelimit.Limiter
+	next    http.Handler
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l := h.limiter
+	if h.keyed != nil {
+		if key := h.key(r); key != "" {
+			l = h.keyed.Get(key)
+		}
+	}
+	if l == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if h.wait {
+		h.serveWaiting(l, w, r)
+	} else {
+		h.serveRejecting(l, w, r)
+	}
+}
+
+func (h *handler) serveWaiting(l ratelimit.Limiter, w http.ResponseWriter, r *http.Request) {
+	cl, ok := l.(ratelimit.ContextLimiter)
+	if !ok {
+		l.Take()
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if _, err := cl.TakeContext(r.Context()); err != nil {
+		// Either the wait would outlast the request's deadline, or the
+		// request was cancelled and the client won't see the response.
+		if rl, ok := l.(ratelimit.ReservingLimiter); ok && errors.Is(err, ratelimit.ErrWaitExceedsDeadline) {
+			w.Header().Set("Retry-After", retryAfterSeconds(retryAfter(rl)))
+		}
+		h.reject.ServeHTTP(w, r)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func (h *handler) serveRejecting(l ratelimit.Limiter, w http.ResponseWriter, r *http.Request) {
+	tl, ok := l.(ratelimit.TryLimiter)
+	if !ok {
+		l.Take()
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if ok, retryAfter := tl.TryTake(); !ok {
+		w.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+		h.reject.ServeHTTP(w, r)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// retryAfter returns how long until l issues a permission, on its own
+// clock. The permission reserved to find out is given back.
+func retryAfter(l ratelimit.ReservingLimiter) time.Duration {
+	res := l.Reserve(1)
+	defer res.Cancel()
+	return res.Delay()
+}
+
+// retryAfterSeconds formats d for the Retry-After header, which only
+// allows whole seconds.
+func retryAfterSeconds(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d > math.MaxInt32*time.Second {
+		d = math.MaxInt32 * time.Second
+	}
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+func tooManyRequests(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
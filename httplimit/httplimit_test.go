@@ -0,0 +1,147 @@
+package httplimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/ratelimit"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddlewareWaiting(t *testing.T) {
+	t.Parallel()
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	h := Middleware(rl)(okHandler)
+
+	w := serve(h, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The next permission is issued after the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	w = serve(h, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Without a deadline, the request waits until it's cancelled.
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	w = serve(h, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestMiddlewareWaitingClock(t *testing.T) {
+	t.Parallel()
+	// The hint is on the clock of the limiter, not the wall clock, which
+	// is an hour behind.
+	clk := clock.NewMock()
+	clk.Set(time.Now().Add(time.Hour))
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack, ratelimit.WithClock(clk))
+	h := Middleware(rl)(okHandler)
+	serve(h, httptest.NewRequest("GET", "/", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	w := serve(h, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Limiters that deny all requests hint at the longest wait.
+	w = serve(Middleware(ratelimit.New(0))(okHandler), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2147483647", w.Header().Get("Retry-After"))
+}
+
+func TestMiddlewareWithoutWaiting(t *testing.T) {
+	t.Parallel()
+	rl := ratelimit.New(1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	h := Middleware(rl, WithoutWaiting)(okHandler)
+
+	w := serve(h, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(h, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
+
+func TestMiddlewareRejectHandler(t *testing.T) {
+	t.Parallel()
+	reject := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	h := Middleware(ratelimit.New(0), WithoutWaiting, WithRejectHandler(reject))(okHandler)
+
+	w := serve(h, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestMiddlewareWithKey(t *testing.T) {
+	t.Parallel()
+	keyed, err := ratelimit.NewKeyed[string](1, ratelimit.Per(time.Minute), ratelimit.WithoutSlack)
+	require.NoError(t, err)
+	h := Middleware(nil, WithoutWaiting, WithKey(keyed, RemoteIP))(okHandler)
+
+	request := func(remoteAddr string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		return serve(h, r).Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1:5678"), "ports should share a key")
+	assert.Equal(t, http.StatusOK, request("10.0.0.2:1234"))
+	assert.Equal(t, 2, keyed.Len())
+
+	// Requests without a key use the limiter given to Middleware.
+	h = Middleware(nil, WithoutWaiting, WithKey(keyed, func(*http.Request) string { return "" }))(okHandler)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request("10.0.0.1:1234"))
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{remoteAddr: "10.0.0.1:1234", want: "10.0.0.1"},
+		{remoteAddr: "[::1]:1234", want: "::1"},
+		{remoteAddr: "10.0.0.1", want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		assert.Equal(t, tt.want, RemoteIP(r), tt.remoteAddr)
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "0", retryAfterSeconds(-time.Second))
+	assert.Equal(t, "1", retryAfterSeconds(time.Millisecond))
+	assert.Equal(t, "2", retryAfterSeconds(2*time.Second))
+	assert.Equal(t, "2147483647", retryAfterSeconds(time.Duration(1<<63-1)))
+}
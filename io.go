@@ -0,0 +1,136 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"io"
+	"math"
+)
+
+// Reader is an io.Reader whose bandwidth is limited, one permission per
+// byte. Build the limiter with the number of bytes per time window as the
+// rate, and the largest read as the burst: reads are no larger than the
+// burst, which is only 11 bytes with the default slack. For example,
+// New(1<<20, WithBurst(32<<10)) allows 1MiB per second, in reads of up to
+// 32KiB.
+type Reader struct {
+	ctx context.Context
+	r   io.Reader
+	l   WeightedLimiter
+}
+
+// NewReader returns a Reader that reads from r at the rate allowed by l.
+func NewReader(r io.Reader, l WeightedLimiter) *Reader {
+	return NewReaderContext(context.Background(), r, l)
+}
+
+// NewReaderContext is like NewReader, but stops waiting for permissions
+// once ctx is done, and returns ctx.Err().
+func NewReaderContext(ctx context.Context, r io.Reader, l WeightedLimiter) *Reader {
+	return &Reader{ctx: ctx, r: r, l: l}
+}
+
+// Read reads at most as many bytes as the limiter allows in a burst, then
+// waits for the permissions of the bytes it read.
+//
+// If the wait is abandoned, Read returns the bytes it read along with the
+// error, and their permissions are given back to the limiter.
+func (r *Reader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if burst := maxBurst(r.l); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if _, werr := r.l.TakeNContext(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Writer is an io.Writer whose bandwidth is limited, one permission per
+// byte. Like for Reader, build the limiter with a burst as large as the
+// writes to the underlying writer should be, such as
+// New(1<<20, WithBurst(32<<10)).
+type Writer struct {
+	ctx context.Context
+	w   io.Writer
+	l   WeightedLimiter
+}
+
+// NewWriter returns a Writer that writes to w at the rate allowed by l.
+func NewWriter(w io.Writer, l WeightedLimiter) *Writer {
+	return NewWriterContext(context.Background(), w, l)
+}
+
+// NewWriterContext is like NewWriter, but stops waiting for permissions
+// once ctx is done, and returns ctx.Err().
+func NewWriterContext(ctx context.Context, w io.Writer, l WeightedLimiter) *Writer {
+	return &Writer{ctx: ctx, w: w, l: l}
+}
+
+// Write waits for the permissions of the bytes before writing them. Writes
+// larger than the limiter allows in a burst are split into several writes
+// to the underlying writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	burst := maxBurst(w.l)
+
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > burst {
+			chunk = chunk[:burst]
+		}
+		if _, err := w.l.TakeNContext(w.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := w.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// maxBurst returns the number of permissions l issues at once when it's
+// been idle, which is at least one. Limiters that don't report their
+// slack through Stats are assumed to have none.
+func maxBurst(l Limiter) int {
+	sl, ok := l.(StatsLimiter)
+	if !ok {
+		return 1
+	}
+	s := sl.Stats()
+	if s.Rate >= Inf || s.Slack == math.MaxInt {
+		// Don't split reads and writes that aren't limited at all, or
+		// whose burst doesn't fit in an int.
+		return math.MaxInt
+	}
+	return s.Slack + 1
+}
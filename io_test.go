@@ -0,0 +1,222 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingWriter records the size of every write.
+type recordingWriter struct {
+	bytes.Buffer
+	sizes []int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.sizes = append(w.sizes, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	start := clk.Now()
+	rl, err := NewWithRate(10, WithSlack(4), WithClock(clk), WithStats())
+	require.NoError(t, err)
+	data := bytes.Repeat([]byte("x"), 20)
+	r := NewReader(bytes.NewReader(data), rl.(WeightedLimiter))
+
+	buf := make([]byte, 100)
+	type result struct {
+		n   int
+		at  time.Time
+		err error
+	}
+	results := make(chan result)
+	go func() {
+		for {
+			n, err := r.Read(buf)
+			results <- result{n: n, at: clk.Now(), err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// Reads are split into bursts of 5 bytes, each taking 500ms.
+	var got []result
+	for len(got) == 0 || got[len(got)-1].err == nil {
+		select {
+		case res := <-results:
+			got = append(got, res)
+		case <-time.After(time.Millisecond):
+			clk.Add(100 * time.Millisecond)
+		}
+	}
+	require.Len(t, got, 5)
+	for i, res := range got[:4] {
+		assert.Equal(t, 5, res.n)
+		assert.NoError(t, res.err)
+		assert.False(t, res.at.Before(start.Add(time.Duration(400+500*i)*time.Millisecond)), "read %d too early", i)
+	}
+	assert.Equal(t, io.EOF, got[4].err)
+	assert.Equal(t, int64(20), rl.(StatsLimiter).Stats().Takes, "should charge one permission per byte")
+}
+
+func TestReaderCancel(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	rl, err := NewWithRate(10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReaderContext(ctx, bytes.NewReader([]byte("abc")), rl.(WeightedLimiter))
+	buf := make([]byte, 3)
+
+	n, err := r.Read(buf)
+	assert.Equal(t, 1, n, "first byte is free")
+	assert.NoError(t, err)
+
+	errs := make(chan error)
+	var startWg sync.WaitGroup
+	startWg.Add(1)
+	go func() {
+		startWg.Done()
+		n, err := r.Read(buf)
+		assert.Equal(t, 1, n)
+		errs <- err
+	}()
+	startWg.Wait()
+	clk.Add(50 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+
+	n, err = r.Read(buf)
+	assert.Zero(t, n)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	rl, err := NewWithRate(10, WithSlack(3), WithClock(clk), WithStats())
+	require.NoError(t, err)
+
+	var dst recordingWriter
+	w := NewWriter(&dst, rl.(WeightedLimiter))
+	data := bytes.Repeat([]byte("y"), 10)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, err := w.Write(data)
+		assert.Equal(t, 10, n)
+		assert.NoError(t, err)
+	}()
+
+	start := clk.Now()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-time.After(time.Millisecond):
+			clk.Add(100 * time.Millisecond)
+		}
+	}
+	assert.Equal(t, data, dst.Bytes())
+	assert.Equal(t, []int{4, 4, 2}, dst.sizes, "should split writes into bursts")
+	assert.False(t, clk.Now().Before(start.Add(900*time.Millisecond)), "10 bytes at 10 per second")
+	assert.Equal(t, int64(10), rl.(StatsLimiter).Stats().Takes)
+}
+
+func TestReaderWriterBurst(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	rl, err := NewWithRate(1<<20, WithBurst(32<<10), WithClock(clk))
+	require.NoError(t, err)
+
+	var dst recordingWriter
+	w := NewWriter(&dst, rl.(WeightedLimiter))
+	r := NewReader(bytes.NewReader(make([]byte, 64<<10)), rl.(WeightedLimiter))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, err := w.Write(make([]byte, 64<<10))
+		assert.Equal(t, 64<<10, n)
+		assert.NoError(t, err)
+
+		n, err = r.Read(make([]byte, 64<<10))
+		assert.Equal(t, 32<<10, n)
+		assert.NoError(t, err)
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-time.After(time.Millisecond):
+			clk.Add(10 * time.Millisecond)
+		}
+	}
+	assert.Equal(t, []int{32 << 10, 32 << 10}, dst.sizes, "should write in bursts")
+}
+
+func TestReaderMaxSlack(t *testing.T) {
+	t.Parallel()
+	rl, err := NewWithRate(1e9, WithSlack(math.MaxInt))
+	require.NoError(t, err)
+	assert.Equal(t, math.MaxInt, maxBurst(rl))
+
+	r := NewReader(bytes.NewReader(make([]byte, 8)), rl.(WeightedLimiter))
+	n, err := r.Read(make([]byte, 8))
+	assert.Equal(t, 8, n)
+	assert.NoError(t, err)
+}
+
+func TestWriterDeadline(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	rl, err := NewWithRate(10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	ctx, cancel := clk.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	var dst recordingWriter
+	w := NewWriterContext(ctx, &dst, rl.(WeightedLimiter))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, err := w.Write([]byte("abcdef"))
+		assert.Equal(t, 3, n)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error %v", err)
+	}()
+
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-time.After(time.Millisecond):
+			clk.Add(100 * time.Millisecond)
+		}
+	}
+	assert.Equal(t, "abc", dst.String())
+}
+
+func TestWriterUnlimited(t *testing.T) {
+	t.Parallel()
+	var dst recordingWriter
+	w := NewWriter(&dst, NewUnlimited().(WeightedLimiter))
+	n, err := w.Write(make([]byte, 1<<20))
+	assert.Equal(t, 1<<20, n)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1 << 20}, dst.sizes, "shouldn't split writes")
+}
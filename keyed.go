@@ -0,0 +1,237 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyedLimiter holds a separate limiter per key, such as an API key, a user
+// or a client IP address. Limiters are created on first use of their key,
+// from the rate and options the KeyedLimiter was built with, or from the
+// override set for the key.
+//
+// Limiters of keys that weren't used for a while are dropped when
+// configured WithIdleTTL or WithMaxKeys; a key that is used again after
+// that starts over with a fresh limiter. Idle limiters are only dropped
+// once they refilled, so that starting over doesn't let keys bypass their
+// rate.
+type KeyedLimiter[K comparable] struct {
+	rate  Rate
+	opts  []Option
+	clock Clock
+
+	idleTTL time.Duration
+	maxKeys int
+
+	mu        sync.Mutex
+	overrides map[K]keyedOverride
+	keys      map[K]*list.Element
+	lru       list.List // of *keyedEntry[K], most recently used first
+}
+
+type keyedOverride struct {
+	rate Rate
+	opts []Option
+}
+
+type keyedEntry[K comparable] struct {
+	key      K
+	limiter  limiter
+	lastUsed time.Time
+	// fresh is the number of permissions available from a new limiter.
+	fresh int
+}
+
+// NewKeyed returns a KeyedLimiter that limits every key to the given rate.
+// The options apply to the limiter of every key, and may include
+// WithIdleTTL and WithMaxKeys to bound the number of keys held.
+func NewKeyed[K comparable](rate Rate, opts ...Option) (*KeyedLimiter[K], error) {
+	// Building a limiter validates the rate and options once for all keys.
+	if _, err := newLimiter(rate, opts...); err != nil {
+		return nil, err
+	}
+	config := buildConfig(opts)
+	if config.idleTTL < 0 {
+		return nil, fmt.Errorf("ratelimit: idle TTL must not be negative, got %v", config.idleTTL)
+	}
+	if config.maxKeys < 0 {
+		return nil, fmt.Errorf("ratelimit: max keys must not be negative, got %d", config.maxKeys)
+	}
+
+	return &KeyedLimiter[K]{
+		rate:      rate,
+		opts:      opts,
+		clock:     config.clock,
+		idleTTL:   config.idleTTL,
+		maxKeys:   config.maxKeys,
+		overrides: make(map[K]keyedOverride),
+		keys:      make(map[K]*list.Element),
+	}, nil
+}
+
+// Get returns the limiter of the key, creating it if needed. It implements
+// all the interfaces that limiters returned by NewWithRate implement.
+func (k *KeyedLimiter[K]) Get(key K) Limiter {
+	return k.get(key)
+}
+
+// Take is like Limiter.Take for the limiter of the key.
+func (k *KeyedLimiter[K]) Take(key K) time.Time {
+	return k.get(key).Take()
+}
+
+// TakeContext is like ContextLimiter.TakeContext for the limiter of the key.
+func (k *KeyedLimiter[K]) TakeContext(ctx context.Context, key K) (time.Time, error) {
+	return k.get(key).TakeContext(ctx)
+}
+
+// TryTake is like TryLimiter.TryTake for the limiter of the key.
+func (k *KeyedLimiter[K]) TryTake(key K) (bool, time.Duration) {
+	return k.get(key).TryTake()
+}
+
+// SetOverride makes the key limited to the given rate instead of the
+// shared one. The options are applied after the shared options. The key
+// starts over with a fresh limiter.
+func (k *KeyedLimiter[K]) SetOverride(key K, rate Rate, opts ...Option) error {
+	opts = append(k.opts[:len(k.opts):len(k.opts)], opts...)
+	if _, err := newLimiter(rate, opts...); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.overrides[key] = keyedOverride{rate: rate, opts: opts}
+	k.remove(key)
+	return nil
+}
+
+// RemoveOverride makes the key limited to the shared rate again. The key
+// starts over with a fresh limiter.
+func (k *KeyedLimiter[K]) RemoveOverride(key K) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.overrides, key)
+	k.remove(key)
+}
+
+// Len returns the number of keys that currently hold a limiter.
+func (k *KeyedLimiter[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.evictIdle(k.clock.Now())
+	return len(k.keys)
+}
+
+func (k *KeyedLimiter[K]) get(key K) limiter {
+	now := k.clock.Now()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.evictIdle(now)
+	if el, ok := k.keys[key]; ok {
+		e := el.Value.(*keyedEntry[K])
+		e.lastUsed = now
+		k.lru.MoveToFront(el)
+		return e.limiter
+	}
+
+	rate, opts := k.rate, k.opts
+	if o, ok := k.overrides[key]; ok {
+		rate, opts = o.rate, o.opts
+	}
+	// The options were validated up front.
+	l, _ := newLimiter(rate, opts...)
+
+	k.keys[key] = k.lru.PushFront(&keyedEntry[K]{
+		key:      key,
+		limiter:  l,
+		lastUsed: now,
+		fresh:    l.Stats().Available,
+	})
+	if k.maxKeys > 0 && len(k.keys) > k.maxKeys {
+		k.remove(k.lru.Back().Value.(*keyedEntry[K]).key)
+	}
+	return l
+}
+
+// evictIdle drops the limiters of keys that weren't used for idleTTL,
+// once they have as many permissions available as a new limiter would.
+// It must be called with the lock held.
+func (k *KeyedLimiter[K]) evictIdle(now time.Time) {
+	if k.idleTTL == 0 {
+		return
+	}
+	for el := k.lru.Back(); el != nil; {
+		e := el.Value.(*keyedEntry[K])
+		if now.Sub(e.lastUsed) < k.idleTTL {
+			return
+		}
+		prev := el.Prev()
+		if e.limiter.Stats().Available >= e.fresh {
+			k.remove(e.key)
+		}
+		el = prev
+	}
+}
+
+// remove drops the limiter of the key. It must be called with the lock held.
+func (k *KeyedLimiter[K]) remove(key K) {
+	if el, ok := k.keys[key]; ok {
+		k.lru.Remove(el)
+		delete(k.keys, key)
+	}
+}
+
+type idleTTLOption time.Duration
+
+func (o idleTTLOption) apply(c *config) {
+	c.idleTTL = time.Duration(o)
+}
+
+// WithIdleTTL configures a KeyedLimiter to drop the limiters of keys that
+// weren't used for the given duration, or until they refilled if that
+// takes longer. It has no effect on other limiters.
+func WithIdleTTL(ttl time.Duration) Option {
+	return idleTTLOption(ttl)
+}
+
+type maxKeysOption int
+
+func (o maxKeysOption) apply(c *config) {
+	c.maxKeys = int(o)
+}
+
+// WithMaxKeys configures a KeyedLimiter to hold at most n keys, dropping
+// the limiters of the least recently used keys first. It has no effect on
+// other limiters.
+func WithMaxKeys(n int) Option {
+	return maxKeysOption(n)
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockClock() *clock.Mock {
+	// Some limiters use the zero time as "non-initialized" state.
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	return clk
+}
+
+func TestKeyed(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	kl, err := NewKeyed[string](10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	ok, _ := kl.TryTake("a")
+	assert.True(t, ok)
+	ok, retryAfter := kl.TryTake("a")
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	ok, _ = kl.TryTake("b")
+	assert.True(t, ok, "keys should be limited separately")
+	assert.Equal(t, 2, kl.Len())
+
+	assert.Equal(t, kl.Get("a"), kl.Get("a"), "limiters should be reused")
+	assert.Implements(t, (*ContextLimiter)(nil), kl.Get("a"))
+}
+
+func TestKeyedOverride(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	kl, err := NewKeyed[string](10, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	require.NoError(t, kl.SetOverride("vip", 100))
+	ok, _ := kl.TryTake("vip")
+	assert.True(t, ok)
+	ok, retryAfter := kl.TryTake("vip")
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Millisecond, retryAfter)
+
+	// The shared options still apply.
+	clk.Add(time.Second)
+	ok, _ = kl.TryTake("vip")
+	assert.True(t, ok)
+	ok, _ = kl.TryTake("vip")
+	assert.False(t, ok, "shouldn't have slack")
+
+	kl.RemoveOverride("vip")
+	ok, _ = kl.TryTake("vip")
+	assert.True(t, ok)
+	ok, retryAfter = kl.TryTake("vip")
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	assert.EqualError(t, kl.SetOverride("vip", -1), "ratelimit: rate must not be negative, got -1")
+}
+
+func TestKeyedIdleTTL(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	kl, err := NewKeyed[string](10, WithClock(clk), WithIdleTTL(time.Minute))
+	require.NoError(t, err)
+
+	kl.TryTake("a")
+	kl.TryTake("b")
+	assert.Equal(t, 2, kl.Len())
+
+	clk.Add(30 * time.Second)
+	kl.TryTake("a")
+	clk.Add(40 * time.Second)
+	assert.Equal(t, 1, kl.Len(), "b should be evicted")
+
+	clk.Add(time.Minute)
+	assert.Zero(t, kl.Len())
+}
+
+func TestKeyedIdleTTLRefill(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	kl, err := NewKeyed[string](1, Per(time.Minute), WithoutSlack, WithClock(clk), WithIdleTTL(10*time.Second))
+	require.NoError(t, err)
+
+	ok, _ := kl.TryTake("a")
+	assert.True(t, ok)
+
+	// The key is idle, but its limiter didn't refill yet, so it's held on
+	// to instead of starting over.
+	clk.Add(11 * time.Second)
+	assert.Equal(t, 1, kl.Len())
+	ok, retryAfter := kl.TryTake("a")
+	assert.False(t, ok)
+	assert.Equal(t, 49*time.Second, retryAfter)
+
+	clk.Add(time.Minute)
+	assert.Zero(t, kl.Len())
+}
+
+func TestKeyedMaxKeys(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	kl, err := NewKeyed[string](10, WithoutSlack, WithClock(clk), WithMaxKeys(2))
+	require.NoError(t, err)
+
+	kl.TryTake("a")
+	kl.TryTake("b")
+	kl.TryTake("a")
+	kl.TryTake("c")
+	assert.Equal(t, 2, kl.Len())
+
+	// b was the least recently used, so it starts over.
+	ok, _ := kl.TryTake("b")
+	assert.True(t, ok)
+	ok, _ = kl.TryTake("c")
+	assert.False(t, ok)
+}
+
+func TestKeyedInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		msg     string
+		rate    Rate
+		opts    []Option
+		wantErr string
+	}{
+		{msg: "rate", rate: -1, wantErr: "ratelimit: rate must not be negative, got -1"},
+		{msg: "option", rate: 1, opts: []Option{Per(0)}, wantErr: "ratelimit: per must be positive, got 0s"},
+		{msg: "idle TTL", rate: 1, opts: []Option{WithIdleTTL(-time.Second)}, wantErr: "ratelimit: idle TTL must not be negative, got -1s"},
+		{msg: "max keys", rate: 1, opts: []Option{WithMaxKeys(-1)}, wantErr: "ratelimit: max keys must not be negative, got -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewKeyed[int](tt.rate, tt.opts...)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestKeyedConcurrent(t *testing.T) {
+	t.Parallel()
+	kl, err := NewKeyed[string](Inf, WithMaxKeys(10), WithIdleTTL(time.Millisecond))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				kl.Take(fmt.Sprint((i * j) % 32))
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, kl.Len(), 10)
+}
@@ -21,6 +21,7 @@
 package ratelimit // import "go.uber.org/ratelimit"
 
 import (
+	"context"
 	"time"
 
 	"sync/atomic"
@@ -32,33 +33,50 @@ type state struct {
 	sleepFor time.Duration
 }
 
+// nextSleepFor calculates how much time the next request
+// should sleep, like Take does.
+func (s *state) nextSleepFor(l *limits, now time.Time) time.Duration {
+	sleepFor := s.sleepFor + l.perRequest - now.Sub(s.last)
+	if sleepFor < -l.maxSlack {
+		sleepFor = -l.maxSlack
+	}
+	return sleepFor
+}
+
 type atomicLimiter struct {
 	state unsafe.Pointer
 	//lint:ignore U1000 Padding is unused but it is crucial to maintain performance
 	// of this rate limiter in case of collocation with other frequently accessed memory.
 	padding [56]byte // cache line size - state pointer size = 64 - 8; created to avoid false sharing.
 
-	perRequest time.Duration
-	maxSlack   time.Duration
-	clock      Clock
+	atomicLimits
+	*counters
+	clock  Clock
+	warmup *warmup
 }
 
 // newAtomicBased returns a new atomic based limiter.
-func newAtomicBased(rate int, opts ...Option) *atomicLimiter {
+func newAtomicBased(rate Rate, opts ...Option) *atomicLimiter {
 	// TODO consider moving config building to the implementation
 	// independent code.
 	config := buildConfig(opts)
-	perRequest := config.per / time.Duration(rate)
 	l := &atomicLimiter{
-		perRequest: perRequest,
-		maxSlack:   -1 * time.Duration(config.slack) * perRequest,
-		clock:      config.clock,
+		counters: config.counters(),
+		clock:    config.clock,
+		warmup:   config.warmup(config.clock.Now()),
 	}
+	l.store(config.limits(rate))
 
 	initialState := state{
 		last:     time.Time{},
 		sleepFor: 0,
 	}
+	now := config.clock.Now()
+	if last, ok := config.initialState(rate, now); ok {
+		// Owing negative sleep is how the limiter accumulates slack.
+		initialState.last = now
+		initialState.sleepFor = last.Sub(now)
+	}
 	atomic.StorePointer(&l.state, unsafe.Pointer(&initialState))
 	return l
 }
@@ -73,6 +91,7 @@ func (t *atomicLimiter) Take() time.Time {
 	)
 	for !taken {
 		now := t.clock.Now()
+		l := t.warmup.limits(t.load(), now)
 
 		previousStatePointer := atomic.LoadPointer(&t.state)
 		oldState := (*state)(previousStatePointer)
@@ -92,12 +111,12 @@ func (t *atomicLimiter) Take() time.Time {
 		// the perRequest budget and how long the last request took.
 		// Since the request may take longer than the budget, this number
 		// can get negative, and is summed across requests.
-		newState.sleepFor += t.perRequest - now.Sub(oldState.last)
+		newState.sleepFor += l.perRequest - now.Sub(oldState.last)
 		// We shouldn't allow sleepFor to get too negative, since it would mean that
 		// a service that slowed down a lot for a short period of time would get
 		// a much higher RPS following that.
-		if newState.sleepFor < t.maxSlack {
-			newState.sleepFor = t.maxSlack
+		if newState.sleepFor < -l.maxSlack {
+			newState.sleepFor = -l.maxSlack
 		}
 		if newState.sleepFor > 0 {
 			newState.last = newState.last.Add(newState.sleepFor)
@@ -105,6 +124,99 @@ func (t *atomicLimiter) Take() time.Time {
 		}
 		taken = atomic.CompareAndSwapPointer(&t.state, previousStatePointer, unsafe.Pointer(&newState))
 	}
+	t.record(1, interval)
 	t.clock.Sleep(interval)
 	return newState.last
 }
+
+// TakeContext is like Take, but can be cancelled through ctx.
+func (t *atomicLimiter) TakeContext(ctx context.Context) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (t *atomicLimiter) TryTake() (bool, time.Duration) {
+	return tryTake(t.clock, t, 1)
+}
+
+// TakeN is like Take, for n permissions.
+func (t *atomicLimiter) TakeN(n int) time.Time {
+	return takeN(t.clock, t, n)
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (t *atomicLimiter) TryTakeN(n int) (bool, time.Duration) {
+	return tryTake(t.clock, t, n)
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (t *atomicLimiter) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, n)
+}
+
+// Reserve takes n permissions without waiting for them.
+func (t *atomicLimiter) Reserve(n int) *Reservation {
+	return newReservation(t.clock, t, n)
+}
+
+func (t *atomicLimiter) reserve(now time.Time, n int, maxWait time.Duration) (time.Time, bool) {
+	for {
+		l := t.warmup.limits(t.load(), now)
+		previousStatePointer := atomic.LoadPointer(&t.state)
+		oldState := (*state)(previousStatePointer)
+
+		newState := state{
+			last:     now,
+			sleepFor: oldState.sleepFor,
+		}
+
+		// Same as in Take, the first request is allowed.
+		if !oldState.last.IsZero() {
+			newState.sleepFor = oldState.nextSleepFor(l, now)
+		}
+		// The rest of the permissions follow the first one.
+		newState.sleepFor += l.tail(n)
+		if newState.sleepFor > 0 {
+			newState.last = newState.last.Add(newState.sleepFor)
+			newState.sleepFor = 0
+		}
+		// Waits of maxPerRequest, which tail saturates at, or longer are
+		// never taken, which keeps the state from overflowing.
+		if wait := newState.last.Sub(now); wait > maxWait || wait >= maxPerRequest {
+			return newState.last, false
+		}
+
+		if atomic.CompareAndSwapPointer(&t.state, previousStatePointer, unsafe.Pointer(&newState)) {
+			return newState.last, true
+		}
+	}
+}
+
+func (t *atomicLimiter) refund(n int) {
+	perRequest := t.load().perRequest
+	for {
+		previousStatePointer := atomic.LoadPointer(&t.state)
+		oldState := (*state)(previousStatePointer)
+
+		// Owing less sleep lets the next callers have
+		// the permissions that were given back.
+		newState := state{
+			last:     oldState.last,
+			sleepFor: oldState.sleepFor - time.Duration(n)*perRequest,
+		}
+		if atomic.CompareAndSwapPointer(&t.state, previousStatePointer, unsafe.Pointer(&newState)) {
+			return
+		}
+	}
+}
+
+// Stats returns a snapshot of the limiter.
+func (t *atomicLimiter) Stats() Stats {
+	now := t.clock.Now()
+	l := t.warmup.limits(t.load(), now)
+	first := now
+	if s := (*state)(atomic.LoadPointer(&t.state)); !s.last.IsZero() {
+		first = now.Add(s.nextSleepFor(l, now))
+	}
+	return newStats(l, t.counters, now, first)
+}
@@ -21,6 +21,7 @@
 package ratelimit // import "go.uber.org/ratelimit"
 
 import (
+	"context"
 	"sync/atomic"
 	"time"
 )
@@ -33,23 +34,31 @@ type atomicInt64Limiter struct {
 	//lint:ignore U1000 like prepadding.
 	postpadding [56]byte // cache line size - state size = 64 - 8; created to avoid false sharing.
 
-	perRequest time.Duration
-	maxSlack   time.Duration
-	clock      Clock
+	atomicLimits
+	*counters
+	clock Clock
+	// spacing is nil if permissions are spaced regularly.
+	spacing  spacing
+	warmup   *warmup
+	schedule *schedule
 }
 
 // newAtomicBased returns a new atomic based limiter.
-func newAtomicInt64Based(rate int, opts ...Option) *atomicInt64Limiter {
+func newAtomicInt64Based(rate Rate, opts ...Option) *atomicInt64Limiter {
 	// TODO consider moving config building to the implementation
 	// independent code.
 	config := buildConfig(opts)
-	perRequest := config.per / time.Duration(rate)
 	l := &atomicInt64Limiter{
-		perRequest: perRequest,
-		maxSlack:   time.Duration(config.slack) * perRequest,
-		clock:      config.clock,
+		counters: config.counters(),
+		clock:    config.clock,
+		spacing:  config.spacing(config.clock.Now()),
+		warmup:   config.warmup(config.clock.Now()),
 	}
+	l.store(config.limits(rate))
 	atomic.StoreInt64(&l.state, 0)
+	if last, ok := config.initialState(rate, config.clock.Now()); ok {
+		atomic.StoreInt64(&l.state, last.UnixNano())
+	}
 	return l
 }
 
@@ -61,21 +70,10 @@ func (t *atomicInt64Limiter) Take() time.Time {
 		now                          int64
 	)
 	for {
-		now = t.clock.Now().UnixNano()
+		clockNow := t.clock.Now()
+		now = clockNow.UnixNano()
 		timeOfNextPermissionIssue := atomic.LoadInt64(&t.state)
-
-		switch {
-		case timeOfNextPermissionIssue == 0 || (t.maxSlack == 0 && now-timeOfNextPermissionIssue > int64(t.perRequest)):
-			// if this is our first call or t.maxSlack == 0 we need to shrink issue time to now
-			newTimeOfNextPermissionIssue = now
-		case t.maxSlack > 0 && now-timeOfNextPermissionIssue > int64(t.maxSlack)+int64(t.perRequest):
-			// a lot of nanoseconds passed since the last Take call
-			// we will limit max accumulated time to maxSlack
-			newTimeOfNextPermissionIssue = now - int64(t.maxSlack)
-		default:
-			// calculate the time at which our permission was issued
-			newTimeOfNextPermissionIssue = timeOfNextPermissionIssue + int64(t.perRequest)
-		}
+		newTimeOfNextPermissionIssue = t.nextPermissionIssue(t.limitsAt(clockNow), now, timeOfNextPermissionIssue)
 
 		if atomic.CompareAndSwapInt64(&t.state, timeOfNextPermissionIssue, newTimeOfNextPermissionIssue) {
 			break
@@ -83,6 +81,7 @@ func (t *atomicInt64Limiter) Take() time.Time {
 	}
 
 	sleepDuration := time.Duration(newTimeOfNextPermissionIssue - now)
+	t.record(1, sleepDuration)
 	if sleepDuration > 0 {
 		t.clock.Sleep(sleepDuration)
 		return time.Unix(0, newTimeOfNextPermissionIssue)
@@ -90,3 +89,109 @@ func (t *atomicInt64Limiter) Take() time.Time {
 	// return now if we don't sleep as atomicLimiter does
 	return time.Unix(0, now)
 }
+
+// nextPermissionIssue calculates the time at which the permission
+// requested at now is issued, given the time of the previous one.
+func nextPermissionIssue(l *limits, now, timeOfNextPermissionIssue int64) int64 {
+	switch {
+	case timeOfNextPermissionIssue == 0 || (l.maxSlack == 0 && now-timeOfNextPermissionIssue > int64(l.perRequest)):
+		// if this is our first call or l.maxSlack == 0 we need to shrink issue time to now
+		return now
+	case l.maxSlack > 0 && now-timeOfNextPermissionIssue > int64(l.maxSlack)+int64(l.perRequest):
+		// a lot of nanoseconds passed since the last Take call
+		// we will limit max accumulated time to maxSlack
+		return now - int64(l.maxSlack)
+	default:
+		// calculate the time at which our permission was issued
+		return timeOfNextPermissionIssue + int64(l.perRequest)
+	}
+}
+
+// nextPermissionIssue is like the function of the same name, with the
+// configured spacing.
+func (t *atomicInt64Limiter) nextPermissionIssue(l *limits, now, timeOfNextPermissionIssue int64) int64 {
+	if t.spacing == nil || timeOfNextPermissionIssue == 0 {
+		return nextPermissionIssue(l, now, timeOfNextPermissionIssue)
+	}
+	// The permission follows the previous one by the gap drawn for it,
+	// and the limiter is idle if the gap has passed, as it would be
+	// after perRequest with regular spacing. Slack is still counted in
+	// average gaps.
+	spaced := *l
+	spaced.perRequest = t.spacing(l.perRequest, timeOfNextPermissionIssue)
+	return nextPermissionIssue(&spaced, now, timeOfNextPermissionIssue)
+}
+
+// TakeContext is like Take, but can be cancelled through ctx.
+func (t *atomicInt64Limiter) TakeContext(ctx context.Context) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (t *atomicInt64Limiter) TryTake() (bool, time.Duration) {
+	return tryTake(t.clock, t, 1)
+}
+
+// TakeN is like Take, for n permissions.
+func (t *atomicInt64Limiter) TakeN(n int) time.Time {
+	return takeN(t.clock, t, n)
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (t *atomicInt64Limiter) TryTakeN(n int) (bool, time.Duration) {
+	return tryTake(t.clock, t, n)
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (t *atomicInt64Limiter) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, n)
+}
+
+// Reserve takes n permissions without waiting for them.
+func (t *atomicInt64Limiter) Reserve(n int) *Reservation {
+	return newReservation(t.clock, t, n)
+}
+
+func (t *atomicInt64Limiter) reserve(now time.Time, n int, maxWait time.Duration) (time.Time, bool) {
+	nowNanos := now.UnixNano()
+	for {
+		l := t.limitsAt(now)
+		timeOfNextPermissionIssue := atomic.LoadInt64(&t.state)
+		// the first permission is issued as in Take, the rest follow it
+		newTimeOfNextPermissionIssue := t.nextPermissionIssue(l, nowNanos, timeOfNextPermissionIssue) +
+			int64(l.tail(n))
+
+		// like Take, report now if the permission doesn't need waiting for
+		wait := time.Duration(newTimeOfNextPermissionIssue - nowNanos)
+		if wait < 0 {
+			wait = 0
+		}
+		// waits of maxPerRequest, which tail saturates at, or longer are
+		// never taken, which keeps the state from overflowing
+		if wait > maxWait || wait >= maxPerRequest {
+			return now.Add(wait), false
+		}
+		if atomic.CompareAndSwapInt64(&t.state, timeOfNextPermissionIssue, newTimeOfNextPermissionIssue) {
+			return now.Add(wait), true
+		}
+	}
+}
+
+func (t *atomicInt64Limiter) refund(n int) {
+	// Moving the time of the last issued permission back lets
+	// the next callers have the permissions that were given back.
+	atomic.AddInt64(&t.state, -int64(n)*int64(t.limitsAt(t.clock.Now()).perRequest))
+}
+
+// limitsAt returns the limits that apply at now.
+func (t *atomicInt64Limiter) limitsAt(now time.Time) *limits {
+	return t.warmup.limits(t.schedule.limits(t.load(), now), now)
+}
+
+// Stats returns a snapshot of the limiter.
+func (t *atomicInt64Limiter) Stats() Stats {
+	now := t.clock.Now()
+	l := t.limitsAt(now)
+	first := nextPermissionIssue(l, now.UnixNano(), atomic.LoadInt64(&t.state))
+	return newStats(l, t.counters, now, time.Unix(0, first))
+}
@@ -21,29 +21,37 @@
 package ratelimit // import "go.uber.org/ratelimit"
 
 import (
+	"context"
 	"sync"
 	"time"
 )
 
 type mutexLimiter struct {
 	sync.Mutex
-	last       time.Time
-	sleepFor   time.Duration
-	perRequest time.Duration
-	maxSlack   time.Duration
-	clock      Clock
+	last     time.Time
+	sleepFor time.Duration
+	limits   limits
+	*counters
+	clock  Clock
+	warmup *warmup
 }
 
 // newMutexBased returns a new mutex based limiter.
-func newMutexBased(rate int, opts ...Option) *mutexLimiter {
+func newMutexBased(rate Rate, opts ...Option) *mutexLimiter {
 	// TODO consider moving config building to the implementation
 	// independent code.
 	config := buildConfig(opts)
-	perRequest := config.per / time.Duration(rate)
 	l := &mutexLimiter{
-		perRequest: perRequest,
-		maxSlack:   -1 * time.Duration(config.slack) * perRequest,
-		clock:      config.clock,
+		limits:   config.limits(rate),
+		counters: config.counters(),
+		clock:    config.clock,
+		warmup:   config.warmup(config.clock.Now()),
+	}
+	now := config.clock.Now()
+	if last, ok := config.initialState(rate, now); ok {
+		// Owing negative sleep is how the limiter accumulates slack.
+		l.last = now
+		l.sleepFor = last.Sub(now)
 	}
 	return l
 }
@@ -59,6 +67,7 @@ func (t *mutexLimiter) Take() time.Time {
 	// If this is our first request, then we allow it.
 	if t.last.IsZero() {
 		t.last = now
+		t.record(1, 0)
 		return t.last
 	}
 
@@ -66,16 +75,18 @@ func (t *mutexLimiter) Take() time.Time {
 	// the perRequest budget and how long the last request took.
 	// Since the request may take longer than the budget, this number
 	// can get negative, and is summed across requests.
-	t.sleepFor += t.perRequest - now.Sub(t.last)
+	l := t.warmup.limits(&t.limits, now)
+	t.sleepFor += l.perRequest - now.Sub(t.last)
 
 	// We shouldn't allow sleepFor to get too negative, since it would mean that
 	// a service that slowed down a lot for a short period of time would get
 	// a much higher RPS following that.
-	if t.sleepFor < t.maxSlack {
-		t.sleepFor = t.maxSlack
+	if t.sleepFor < -l.maxSlack {
+		t.sleepFor = -l.maxSlack
 	}
 
 	// If sleepFor is positive, then we should sleep now.
+	t.record(1, t.sleepFor)
 	if t.sleepFor > 0 {
 		t.clock.Sleep(t.sleepFor)
 		t.last = now.Add(t.sleepFor)
@@ -86,3 +97,140 @@ func (t *mutexLimiter) Take() time.Time {
 
 	return t.last
 }
+
+// nextSleepFor calculates how much time the next request
+// should sleep, like Take does. It must be called with the lock held.
+func (t *mutexLimiter) nextSleepFor(now time.Time) time.Duration {
+	l := t.warmup.limits(&t.limits, now)
+	sleepFor := t.sleepFor + l.perRequest - now.Sub(t.last)
+	if sleepFor < -l.maxSlack {
+		sleepFor = -l.maxSlack
+	}
+	return sleepFor
+}
+
+// TakeContext is like Take, but can be cancelled through ctx.
+//
+// Unlike Take, it doesn't hold the lock while waiting.
+func (t *mutexLimiter) TakeContext(ctx context.Context) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (t *mutexLimiter) TryTake() (bool, time.Duration) {
+	return tryTake(t.clock, t, 1)
+}
+
+// TakeN is like Take, for n permissions.
+func (t *mutexLimiter) TakeN(n int) time.Time {
+	return takeN(t.clock, t, n)
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (t *mutexLimiter) TryTakeN(n int) (bool, time.Duration) {
+	return tryTake(t.clock, t, n)
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (t *mutexLimiter) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, n)
+}
+
+// Reserve takes n permissions without waiting for them.
+func (t *mutexLimiter) Reserve(n int) *Reservation {
+	return newReservation(t.clock, t, n)
+}
+
+func (t *mutexLimiter) reserve(now time.Time, n int, maxWait time.Duration) (time.Time, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	// Same as in Take, the first request is allowed.
+	last, sleepFor := now, t.sleepFor
+	if !t.last.IsZero() {
+		sleepFor = t.nextSleepFor(now)
+	}
+	// The rest of the permissions follow the first one.
+	sleepFor += t.warmup.limits(&t.limits, now).tail(n)
+	if sleepFor > 0 {
+		last, sleepFor = now.Add(sleepFor), 0
+	}
+	// Waits of maxPerRequest, which tail saturates at, or longer are never
+	// taken, which keeps the state from overflowing.
+	if wait := last.Sub(now); wait > maxWait || wait >= maxPerRequest {
+		return last, false
+	}
+
+	t.last, t.sleepFor = last, sleepFor
+	return last, true
+}
+
+func (t *mutexLimiter) refund(n int) {
+	t.Lock()
+	defer t.Unlock()
+
+	// Owing less sleep lets the next callers have
+	// the permissions that were given back.
+	t.sleepFor -= time.Duration(n) * t.limits.perRequest
+}
+
+// SetRate changes the number of permissions per time window.
+func (t *mutexLimiter) SetRate(rate Rate) error {
+	if err := checkRate(rate); err != nil {
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if err := checkRateLow(rate, t.limits.per); err != nil {
+		return err
+	}
+	t.limits = newLimits(rate, t.limits.per, t.limits.slack)
+	return nil
+}
+
+// SetLimit changes the number of permissions and their time window.
+func (t *mutexLimiter) SetLimit(rate Rate, per time.Duration) error {
+	if err := checkRate(rate); err != nil {
+		return err
+	}
+	if err := checkPer(per); err != nil {
+		return err
+	}
+	if err := checkRateLow(rate, per); err != nil {
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.limits = newLimits(rate, per, t.limits.slack)
+	return nil
+}
+
+// SetSlack changes the number of permissions accumulated for bursts.
+func (t *mutexLimiter) SetSlack(slack int) error {
+	if err := checkSlack(slack); err != nil {
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.limits = newLimits(t.limits.rate, t.limits.per, slack)
+	return nil
+}
+
+// Stats returns a snapshot of the limiter.
+func (t *mutexLimiter) Stats() Stats {
+	t.Lock()
+	defer t.Unlock()
+
+	now := t.clock.Now()
+	first := now
+	if !t.last.IsZero() {
+		first = now.Add(t.nextSleepFor(now))
+	}
+	return newStats(t.warmup.limits(&t.limits, now), t.counters, now, first)
+}
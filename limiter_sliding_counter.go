@@ -0,0 +1,248 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// NewSlidingWindowCounter returns a Limiter that issues at most limit
+// permissions in a rolling time window, which is one second unless
+// configured otherwise with Per. Like many servers do, it approximates the
+// rolling window with two fixed ones, aligned on multiples of the window
+// since the Unix epoch: the count of the current window, plus the count of
+// the previous one weighted by how much of it the rolling window overlaps.
+// It only needs constant memory.
+//
+// Unlike the limiters returned by New, it doesn't space permissions out:
+// up to limit of them can be issued at once. WithSlack has no effect.
+func NewSlidingWindowCounter(limit int, opts ...Option) (Limiter, error) {
+	config := buildConfig(opts)
+	if err := checkWindowLimit(limit, config); err != nil {
+		return nil, err
+	}
+	return &slidingCounterLimiter{
+		limit:    limit,
+		per:      config.per,
+		counters: config.counters(),
+		clock:    config.clock,
+	}, nil
+}
+
+type slidingCounterLimiter struct {
+	sync.Mutex
+	limit int
+	per   time.Duration
+	state windowCounts
+
+	*counters
+	clock Clock
+}
+
+// windowCounts are the counts of the current and the previous windows.
+type windowCounts struct {
+	// start of the current window, in unix nanoseconds.
+	start int64
+	prev  int
+	cur   int
+}
+
+// advance moves the windows forward so that the current one holds at.
+func (w *windowCounts) advance(per time.Duration, at int64) {
+	start := at - at%int64(per)
+	switch {
+	case start <= w.start:
+		return
+	case start == w.start+int64(per):
+		w.prev, w.cur = w.cur, 0
+	default:
+		w.prev, w.cur = 0, 0
+	}
+	w.start = start
+}
+
+// estimate returns the number of permissions issued in the rolling window
+// that ends at at, which must be in the current window.
+func (w *windowCounts) estimate(per time.Duration, at int64) float64 {
+	overlap := float64(int64(per)-(at-w.start)) / float64(per)
+	return float64(w.prev)*overlap + float64(w.cur)
+}
+
+// next returns the time the next permission requested at now is issued,
+// and moves the windows forward to it.
+//
+// Windows don't move back for permissions requested before the current
+// one, which may start in the future if permissions were reserved. Such
+// permissions are issued in the current window, which doesn't let them
+// exceed the limit: the weight of the previous window only decreases as
+// the window goes on.
+func (w *windowCounts) next(limit int, per time.Duration, now int64) int64 {
+	at := now
+	if w.start > at {
+		at = w.start
+	}
+	for {
+		w.advance(per, at)
+		if w.estimate(per, at)+1 <= float64(limit) {
+			return at
+		}
+
+		room := limit - 1 - w.cur
+		next := w.start + int64(per)
+		if room >= 0 && w.prev > 0 {
+			// The weight of the previous window decreases as the rolling
+			// window moves on, until it leaves room for a permission.
+			elapsed := math.Ceil(float64(per) * float64(w.prev-room) / float64(w.prev))
+			if t := w.start + int64(elapsed); t < next {
+				next = t
+			}
+		}
+		if next <= at {
+			// Rounding errors shouldn't stop the time from moving on.
+			next = at + 1
+		}
+		at = next
+	}
+}
+
+// Take blocks until the rolling window has room for a permission.
+func (t *slidingCounterLimiter) Take() time.Time {
+	return takeN(t.clock, t, 1)
+}
+
+// TakeContext is like Take, but can be cancelled through ctx.
+func (t *slidingCounterLimiter) TakeContext(ctx context.Context) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (t *slidingCounterLimiter) TryTake() (bool, time.Duration) {
+	return tryTake(t.clock, t, 1)
+}
+
+// TakeN is like Take, for n permissions.
+func (t *slidingCounterLimiter) TakeN(n int) time.Time {
+	return takeN(t.clock, t, n)
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (t *slidingCounterLimiter) TryTakeN(n int) (bool, time.Duration) {
+	return tryTake(t.clock, t, n)
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (t *slidingCounterLimiter) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, n)
+}
+
+// Reserve takes n permissions without waiting for them.
+func (t *slidingCounterLimiter) Reserve(n int) *Reservation {
+	return newReservation(t.clock, t, n)
+}
+
+func (t *slidingCounterLimiter) reserve(now time.Time, n int, maxWait time.Duration) (time.Time, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	// Like the limiters returned by New, waits longer than maxPerRequest
+	// are never taken.
+	if maxWait > maxPerRequest {
+		maxWait = maxPerRequest
+	}
+	nowNanos := now.UnixNano()
+	deadline := nowNanos + int64(maxWait)
+
+	// Permissions are issued one after another, as many at once as the
+	// rolling window has room for.
+	state := t.state
+	var last int64
+	for left := n; left > 0; {
+		at := state.next(t.limit, t.per, nowNanos)
+		if at > last {
+			last = at
+		}
+		if at > deadline {
+			return time.Unix(0, at), false
+		}
+		// No more than limit permissions are counted in a window, so the
+		// last one is issued that many windows later at least.
+		if windows := int64((left - 1) / t.limit); windows > (deadline-state.start)/int64(t.per) {
+			// It's too late, and too long to find out how late exactly.
+			if windows > (math.MaxInt64-state.start)/int64(t.per) {
+				return time.Unix(0, math.MaxInt64), false
+			}
+			return time.Unix(0, state.start+windows*int64(t.per)), false
+		}
+
+		room := int(float64(t.limit) - state.estimate(t.per, at))
+		if room < 1 {
+			room = 1
+		}
+		if room > left {
+			room = left
+		}
+		state.cur += room
+		left -= room
+	}
+
+	t.state = state
+	return time.Unix(0, last), true
+}
+
+func (t *slidingCounterLimiter) refund(n int) {
+	t.Lock()
+	defer t.Unlock()
+
+	// The permissions may have been counted in the previous window, if
+	// they were reserved across its end. The windows stay where the
+	// permissions moved them.
+	t.state.cur -= n
+	if t.state.cur < 0 {
+		t.state.prev += t.state.cur
+		t.state.cur = 0
+	}
+	if t.state.prev < 0 {
+		t.state.prev = 0
+	}
+}
+
+// Stats returns a snapshot of the limiter.
+func (t *slidingCounterLimiter) Stats() Stats {
+	t.Lock()
+	defer t.Unlock()
+
+	now := t.clock.Now()
+	state := t.state
+	s := Stats{
+		Rate:           Rate(t.limit),
+		Per:            t.per,
+		Slack:          t.limit - 1,
+		NextPermission: time.Unix(0, state.next(t.limit, t.per, now.UnixNano())),
+	}
+	if !s.NextPermission.After(now) {
+		s.NextPermission = now
+		s.Available = int(float64(t.limit) - state.estimate(t.per, now.UnixNano()))
+	}
+	return t.counters.fill(s)
+}
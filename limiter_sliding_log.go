@@ -0,0 +1,222 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// NewSlidingWindowLog returns a Limiter that issues at most limit
+// permissions in any time window, which is one second unless configured
+// otherwise with Per. It remembers the time of every permission issued
+// in the last window, so it mirrors the accounting of servers that count
+// requests in a rolling window exactly, at the cost of memory in
+// proportion to limit.
+//
+// Unlike the limiters returned by New, it doesn't space permissions out:
+// up to limit of them can be issued at once. WithSlack has no effect.
+func NewSlidingWindowLog(limit int, opts ...Option) (Limiter, error) {
+	config := buildConfig(opts)
+	if err := checkWindowLimit(limit, config); err != nil {
+		return nil, err
+	}
+	return &slidingLogLimiter{
+		limit:    limit,
+		per:      config.per,
+		counters: config.counters(),
+		clock:    config.clock,
+	}, nil
+}
+
+// checkWindowLimit validates the settings of a window limiter.
+func checkWindowLimit(limit int, c config) error {
+	if limit < 1 {
+		return fmt.Errorf("ratelimit: limit must be positive, got %d", limit)
+	}
+	return checkPer(c.per)
+}
+
+type slidingLogLimiter struct {
+	sync.Mutex
+	limit int
+	per   time.Duration
+	// log holds the times permissions were issued at, in unix nanoseconds,
+	// oldest first. Reserved permissions may be issued in the future. Of
+	// the permissions reserved at once, it only holds the last limit ones,
+	// which are all that remain in the window by the time the last of
+	// them is issued.
+	log []int64
+
+	*counters
+	clock Clock
+}
+
+// Take blocks until fewer than limit permissions were issued in the
+// last window.
+func (t *slidingLogLimiter) Take() time.Time {
+	return takeN(t.clock, t, 1)
+}
+
+// TakeContext is like Take, but can be cancelled through ctx.
+func (t *slidingLogLimiter) TakeContext(ctx context.Context) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (t *slidingLogLimiter) TryTake() (bool, time.Duration) {
+	return tryTake(t.clock, t, 1)
+}
+
+// TakeN is like Take, for n permissions.
+func (t *slidingLogLimiter) TakeN(n int) time.Time {
+	return takeN(t.clock, t, n)
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (t *slidingLogLimiter) TryTakeN(n int) (bool, time.Duration) {
+	return tryTake(t.clock, t, n)
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (t *slidingLogLimiter) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	return takeContext(ctx, t.clock, t, n)
+}
+
+// Reserve takes n permissions without waiting for them.
+func (t *slidingLogLimiter) Reserve(n int) *Reservation {
+	return newReservation(t.clock, t, n)
+}
+
+func (t *slidingLogLimiter) reserve(now time.Time, n int, maxWait time.Duration) (time.Time, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	// Like the limiters returned by New, waits longer than maxPerRequest
+	// are never taken.
+	if maxWait > maxPerRequest {
+		maxWait = maxPerRequest
+	}
+	nowNanos := now.UnixNano()
+	t.expire(nowNanos)
+
+	// Permissions are issued one after another, and the first limit of
+	// them set the pace of the others, which follow them a window later.
+	committed := len(t.log)
+	for i := 0; i < n && i < t.limit; i++ {
+		t.log = append(t.log, t.next(nowNanos))
+	}
+	first := t.log[committed:]
+	issuedAt := t.nth(first, n-1)
+
+	if time.Duration(issuedAt-nowNanos) > maxWait {
+		t.log = t.log[:committed]
+		return time.Unix(0, issuedAt), false
+	}
+	if n > len(first) {
+		last := make([]int64, len(first))
+		for i := range last {
+			last[i] = t.nth(first, n-len(last)+i)
+		}
+		t.log = append(t.log[:committed], last...)
+	}
+	return time.Unix(0, issuedAt), true
+}
+
+// nth returns the time the i-th of the permissions reserved at once is
+// issued at, given the times of the first limit of them, or of all of them
+// if there are fewer.
+func (t *slidingLogLimiter) nth(first []int64, i int) int64 {
+	at := first[i%len(first)]
+	windows := int64(i / len(first))
+	if windows > (math.MaxInt64-at)/int64(t.per) {
+		return math.MaxInt64
+	}
+	return at + windows*int64(t.per)
+}
+
+// next returns the time the next permission requested at now is issued:
+// once the permission limit places before it has left the window, and not
+// before the latest one. It must be called with the lock held.
+func (t *slidingLogLimiter) next(nowNanos int64) int64 {
+	issuedAt := nowNanos
+	if len(t.log) > 0 && t.log[len(t.log)-1] > issuedAt {
+		issuedAt = t.log[len(t.log)-1]
+	}
+	if len(t.log) >= t.limit {
+		if at := t.log[len(t.log)-t.limit] + int64(t.per); at > issuedAt {
+			issuedAt = at
+		}
+	}
+	return issuedAt
+}
+
+// expire forgets the permissions that left the window. It must be called
+// with the lock held.
+func (t *slidingLogLimiter) expire(nowNanos int64) {
+	i := 0
+	for i < len(t.log) && t.log[i] <= nowNanos-int64(t.per) {
+		i++
+	}
+	if i > 0 {
+		t.log = append(t.log[:0], t.log[i:]...)
+	}
+}
+
+func (t *slidingLogLimiter) refund(n int) {
+	t.Lock()
+	defer t.Unlock()
+
+	// The latest permissions are the ones that were reserved last, of
+	// which the log holds at most limit.
+	if n > t.limit {
+		n = t.limit
+	}
+	if n > len(t.log) {
+		n = len(t.log)
+	}
+	t.log = t.log[:len(t.log)-n]
+}
+
+// Stats returns a snapshot of the limiter.
+func (t *slidingLogLimiter) Stats() Stats {
+	t.Lock()
+	defer t.Unlock()
+
+	now := t.clock.Now()
+	nowNanos := now.UnixNano()
+	t.expire(nowNanos)
+	s := Stats{
+		Rate:           Rate(t.limit),
+		Per:            t.per,
+		Slack:          t.limit - 1,
+		NextPermission: time.Unix(0, t.next(nowNanos)),
+	}
+	if !s.NextPermission.After(now) {
+		// None of the permissions in the log are in the future.
+		s.NextPermission = now
+		s.Available = t.limit - len(t.log)
+	}
+	return t.counters.fill(s)
+}
@@ -0,0 +1,238 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAlignedClock returns a mock clock at the start of a minute, so that
+// fixed windows start with the test.
+func newAlignedClock() *clock.Mock {
+	clk := clock.NewMock()
+	clk.Set(time.Unix(1699999980, 0))
+	return clk
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	rl, err := NewSlidingWindowLog(3, WithClock(clk))
+	require.NoError(t, err)
+	tl := rl.(TryLimiter)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := tl.TryTake()
+		assert.True(t, ok, "should issue a burst of %d", i+1)
+	}
+	ok, retryAfter := tl.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	clk.Add(900 * time.Millisecond)
+	ok, retryAfter = tl.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	// The first permissions leave the window a second after they're issued.
+	clk.Add(100 * time.Millisecond)
+	ok, _ = tl.TryTake()
+	assert.True(t, ok)
+	assert.Equal(t, 2, rl.(StatsLimiter).Stats().Available)
+}
+
+func TestSlidingWindowLogRolling(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	rl, err := NewSlidingWindowLog(3, WithClock(clk))
+	require.NoError(t, err)
+	wl := rl.(WeightedLimiter)
+
+	ok, _ := wl.TryTakeN(1)
+	assert.True(t, ok)
+	clk.Add(800 * time.Millisecond)
+	ok, _ = wl.TryTakeN(2)
+	assert.True(t, ok)
+
+	// Unlike fixed windows, the permissions of 800ms ago still count.
+	clk.Add(400 * time.Millisecond)
+	ok, retryAfter := wl.TryTakeN(2)
+	assert.False(t, ok)
+	assert.Equal(t, 600*time.Millisecond, retryAfter)
+	assert.Equal(t, 1, rl.(StatsLimiter).Stats().Available)
+}
+
+func TestSlidingWindowLogTake(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	rl, err := NewSlidingWindowLog(2, WithClock(clk))
+	require.NoError(t, err)
+	start := clk.Now()
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts := rl.Take()
+			mu.Lock()
+			times = append(times, ts)
+			mu.Unlock()
+		}()
+	}
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(times) == 2
+	}, time.Second, time.Millisecond)
+	for i := 0; i < 3; i++ {
+		clk.Add(time.Second)
+	}
+	wg.Wait()
+
+	counts := make(map[time.Duration]int)
+	for _, ts := range times {
+		counts[ts.Sub(start)]++
+	}
+	assert.Equal(t, map[time.Duration]int{0: 2, time.Second: 2, 2 * time.Second: 1}, counts)
+}
+
+func TestSlidingWindowLogReserveCancel(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	rl, err := NewSlidingWindowLog(1, WithClock(clk))
+	require.NoError(t, err)
+
+	rl.Take()
+	res := rl.(ReservingLimiter).Reserve(1)
+	assert.Equal(t, time.Second, res.Delay())
+	res.Cancel()
+
+	clk.Add(time.Second)
+	ok, _ := rl.(TryLimiter).TryTake()
+	assert.True(t, ok, "the cancelled permission should be given back")
+}
+
+func TestSlidingWindowLogLargeN(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	start := clk.Now()
+	rl, err := NewSlidingWindowLog(10, WithClock(clk))
+	require.NoError(t, err)
+
+	ok, retryAfter := rl.(WeightedLimiter).TryTakeN(20_000_000)
+	assert.False(t, ok)
+	assert.Equal(t, 1_999_999*time.Second, retryAfter)
+	assert.Empty(t, rl.(*slidingLogLimiter).log, "a rejected reservation shouldn't be logged")
+	assert.False(t, rl.(ReservingLimiter).Reserve(math.MaxInt).OK())
+
+	res := rl.(ReservingLimiter).Reserve(25)
+	assert.Equal(t, start.Add(2*time.Second), res.IssuedAt())
+	assert.Len(t, rl.(*slidingLogLimiter).log, 10, "only the last permissions should be logged")
+	assert.Equal(t, start.Add(2*time.Second), rl.(ReservingLimiter).Reserve(4).IssuedAt())
+	assert.Equal(t, start.Add(3*time.Second), rl.(ReservingLimiter).Reserve(2).IssuedAt())
+}
+
+func TestSlidingWindowCounter(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	rl, err := NewSlidingWindowCounter(10, WithClock(clk))
+	require.NoError(t, err)
+	wl := rl.(WeightedLimiter)
+
+	ok, _ := wl.TryTakeN(10)
+	assert.True(t, ok)
+	ok, retryAfter := wl.TryTakeN(1)
+	assert.False(t, ok)
+	assert.Equal(t, 1100*time.Millisecond, retryAfter, "the previous window should weigh 90%")
+
+	// Halfway through the next window, half of the previous one counts.
+	clk.Add(1500 * time.Millisecond)
+	assert.Equal(t, 5, rl.(StatsLimiter).Stats().Available)
+	ok, _ = wl.TryTakeN(5)
+	assert.True(t, ok)
+	ok, retryAfter = wl.TryTakeN(1)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	// Windows that are more than a window ago don't count.
+	clk.Add(2 * time.Second)
+	ok, _ = wl.TryTakeN(10)
+	assert.True(t, ok)
+}
+
+func TestSlidingWindowCounterTakeN(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	rl, err := NewSlidingWindowCounter(2, Per(time.Minute), WithClock(clk))
+	require.NoError(t, err)
+	start := clk.Now()
+
+	// The third permission waits for the next window, until the first
+	// pair weighs half. The fourth one waits for the window after.
+	res := rl.(ReservingLimiter).Reserve(4)
+	assert.Equal(t, 2*time.Minute, res.Delay())
+	ok, retryAfter := rl.(TryLimiter).TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, 3*time.Minute, retryAfter)
+
+	// The permissions given back can be taken again in the window the
+	// reservation reached.
+	res.Cancel()
+	res = rl.(ReservingLimiter).Reserve(2)
+	assert.Equal(t, start.Add(2*time.Minute), res.IssuedAt())
+}
+
+func TestSlidingWindowCounterLargeN(t *testing.T) {
+	t.Parallel()
+	clk := newAlignedClock()
+	rl, err := NewSlidingWindowCounter(10, WithClock(clk))
+	require.NoError(t, err)
+
+	// Permissions that can't be issued in time are rejected without
+	// counting them one by one.
+	for _, n := range []int{20_000_000, math.MaxInt} {
+		ok, _ := rl.(WeightedLimiter).TryTakeN(n)
+		assert.False(t, ok, "take %d", n)
+	}
+	assert.False(t, rl.(ReservingLimiter).Reserve(math.MaxInt).OK())
+	ctx, cancel := clk.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_, err = rl.(WeightedLimiter).TakeNContext(ctx, 20_000_000)
+	assert.Equal(t, ErrWaitExceedsDeadline, err)
+
+	ok, _ := rl.(WeightedLimiter).TryTakeN(10)
+	assert.True(t, ok, "nothing should have been counted")
+}
+
+func TestSlidingWindowInvalid(t *testing.T) {
+	t.Parallel()
+	constructors := map[string]func(int, ...Option) (Limiter, error){
+		"log":     NewSlidingWindowLog,
+		"counter": NewSlidingWindowCounter,
+	}
+
+	for name, newLimiter := range constructors {
+		t.Run(name, func(t *testing.T) {
+			_, err := newLimiter(0)
+			assert.EqualError(t, err, "ratelimit: limit must be positive, got 0")
+			_, err = newLimiter(1, Per(-time.Second))
+			assert.EqualError(t, err, "ratelimit: per must be positive, got -1s")
+
+			rl, err := newLimiter(1)
+			require.NoError(t, err)
+			_, err = Combine(rl, New(10))
+			assert.NoError(t, err, "should be combinable")
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Rate is a number of permissions per time window, which is one second
+// unless configured otherwise with Per. It may be fractional.
+type Rate float64
+
+// Inf is the rate of a limiter that doesn't limit at all.
+const Inf = Rate(math.MaxFloat64)
+
+// limits are the settings of a limiter that can change at runtime.
+type limits struct {
+	rate  Rate
+	per   time.Duration
+	slack int
+
+	perRequest time.Duration
+	maxSlack   time.Duration
+}
+
+// limits returns the limits for the given rate.
+func (c config) limits(rate Rate) limits {
+	return newLimits(rate, c.per, c.slack)
+}
+
+// maxPerRequest is the longest time between permissions that limiters
+// track. It leaves room to add it to the current time, and to itself.
+const maxPerRequest = time.Duration(math.MaxInt64 / 4)
+
+// tail returns how long after the first of n permissions the last one is
+// issued, saturated at maxPerRequest.
+func (l *limits) tail(n int) time.Duration {
+	if n <= 1 {
+		return 0
+	}
+	if l.perRequest > 0 && int64(n-1) > int64(maxPerRequest/l.perRequest) {
+		return maxPerRequest
+	}
+	return time.Duration(n-1) * l.perRequest
+}
+
+func newLimits(rate Rate, per time.Duration, slack int) limits {
+	// Rates are checked with checkRateLow, but the ones derived from them,
+	// such as during a warmup, may still be lower.
+	perRequest := maxPerRequest
+	if d := float64(per) / float64(rate); d < float64(maxPerRequest) {
+		perRequest = time.Duration(d)
+	}
+	maxSlack := maxPerRequest
+	if slack == 0 || perRequest <= maxPerRequest/time.Duration(slack) {
+		maxSlack = time.Duration(slack) * perRequest
+	}
+	return limits{
+		rate:       rate,
+		per:        per,
+		slack:      slack,
+		perRequest: perRequest,
+		maxSlack:   maxSlack,
+	}
+}
+
+// checkRate reports rates that a running limiter can't be changed to.
+func checkRate(rate Rate) error {
+	// NaN fails this check too.
+	if !(rate > 0) {
+		return fmt.Errorf("ratelimit: rate must be positive, got %v", float64(rate))
+	}
+	return nil
+}
+
+// checkRateLow reports positive rates too low for the time between their
+// permissions to be tracked.
+func checkRateLow(rate Rate, per time.Duration) error {
+	if float64(per)/float64(rate) > float64(maxPerRequest) {
+		return fmt.Errorf("ratelimit: rate is too low, got %v per %v", float64(rate), per)
+	}
+	return nil
+}
+
+func checkPer(per time.Duration) error {
+	if per <= 0 {
+		return fmt.Errorf("ratelimit: per must be positive, got %v", per)
+	}
+	return nil
+}
+
+// initialState returns the time of the last permission issued before a
+// bucket created at now holds the configured tokens, and false if the
+// limiter should start as it does by default.
+func (c config) initialState(rate Rate, now time.Time) (time.Time, bool) {
+	if !c.hasInitialTokens {
+		return time.Time{}, false
+	}
+	// The next permission is issued a perRequest after the last one.
+	return now.Add(-time.Duration(c.initialTokens) * c.limits(rate).perRequest), true
+}
+
+func checkSlack(slack int) error {
+	if slack < 0 {
+		return fmt.Errorf("ratelimit: slack must not be negative, got %d", slack)
+	}
+	return nil
+}
+
+// atomicLimits holds limits that can be swapped while they're in use.
+type atomicLimits struct {
+	p atomic.Pointer[limits]
+}
+
+func (a *atomicLimits) load() *limits {
+	return a.p.Load()
+}
+
+func (a *atomicLimits) store(l limits) {
+	a.p.Store(&l)
+}
+
+// update atomically replaces the limits with the result of fn, unless it
+// fails.
+func (a *atomicLimits) update(fn func(limits) (limits, error)) error {
+	for {
+		old := a.p.Load()
+		l, err := fn(*old)
+		if err != nil {
+			return err
+		}
+		if a.p.CompareAndSwap(old, &l) {
+			return nil
+		}
+	}
+}
+
+// SetRate changes the number of permissions per time window.
+func (a *atomicLimits) SetRate(rate Rate) error {
+	if err := checkRate(rate); err != nil {
+		return err
+	}
+	return a.update(func(l limits) (limits, error) {
+		if err := checkRateLow(rate, l.per); err != nil {
+			return l, err
+		}
+		return newLimits(rate, l.per, l.slack), nil
+	})
+}
+
+// SetLimit changes the number of permissions and their time window.
+func (a *atomicLimits) SetLimit(rate Rate, per time.Duration) error {
+	if err := checkRate(rate); err != nil {
+		return err
+	}
+	if err := checkPer(per); err != nil {
+		return err
+	}
+	if err := checkRateLow(rate, per); err != nil {
+		return err
+	}
+	return a.update(func(l limits) (limits, error) { return newLimits(rate, per, l.slack), nil })
+}
+
+// SetSlack changes the number of permissions accumulated for bursts.
+func (a *atomicLimits) SetSlack(slack int) error {
+	if err := checkSlack(slack); err != nil {
+		return err
+	}
+	return a.update(func(l limits) (limits, error) { return newLimits(l.rate, l.per, slack), nil })
+}
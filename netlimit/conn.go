@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package netlimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"go.uber.org/ratelimit"
+)
+
+type sharedOption struct {
+	l ratelimit.WeightedLimiter
+}
+
+func (o sharedOption) apply(c *config) {
+	c.shared = o.l
+}
+
+// WithSharedLimiter configures a connection to also charge the bytes it
+// reads and writes to l, which may be shared by many connections to cap
+// their aggregate bandwidth. It only applies to NewConn.
+func WithSharedLimiter(l ratelimit.WeightedLimiter) Option {
+	return sharedOption{l: l}
+}
+
+type conn struct {
+	net.Conn
+	r io.Reader
+	w io.Writer
+
+	// ctx is done once the connection is closed, to stop waiting.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewConn returns a connection whose bandwidth is limited to one
+// permission per byte of read and write, which are limited independently.
+// Either limiter may be nil to leave that direction unlimited.
+//
+// Reads and writes are split into chunks no larger than the limiters'
+// bursts, as done by ratelimit.Reader and ratelimit.Writer, so the
+// limiters should be built WithBurst of a useful size, such as 32KiB.
+// Closing the connection stops any read or write waiting for permissions,
+// but read and write deadlines only apply to the underlying connection.
+func NewConn(c net.Conn, read, write ratelimit.WeightedLimiter, opts ...Option) net.Conn {
+	cfg := config{}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc := &conn{Conn: c, r: c, w: c, ctx: ctx, cancel: cancel}
+	// The shared limiter is charged after the connection's own, so that
+	// connections waiting for their own permissions don't hold back others.
+	// Readers are charged from the inside out, and writers the other way.
+	for _, l := range []ratelimit.WeightedLimiter{read, cfg.shared} {
+		if l != nil {
+			lc.r = ratelimit.NewReaderContext(ctx, lc.r, l)
+		}
+	}
+	for _, l := range []ratelimit.WeightedLimiter{cfg.shared, write} {
+		if l != nil {
+			lc.w = ratelimit.NewWriterContext(ctx, lc.w, l)
+		}
+	}
+	return lc
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	return n, c.closedErr(err)
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	return n, c.closedErr(err)
+}
+
+// Close closes the connection, and stops any read or write waiting for
+// permissions.
+func (c *conn) Close() error {
+	c.cancel()
+	return c.Conn.Close()
+}
+
+// closedErr reports waits stopped by Close like the reads and writes of a
+// closed connection.
+func (c *conn) closedErr(err error) error {
+	if errors.Is(err, context.Canceled) && c.ctx.Err() != nil {
+		return net.ErrClosed
+	}
+	return err
+}
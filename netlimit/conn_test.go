@@ -0,0 +1,146 @@
+package netlimit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/ratelimit"
+)
+
+func newLimiter(t *testing.T, rate ratelimit.Rate, opts ...ratelimit.Option) ratelimit.WeightedLimiter {
+	l, err := ratelimit.NewWithRate(rate, append(opts, ratelimit.WithStats())...)
+	require.NoError(t, err)
+	return l.(ratelimit.WeightedLimiter)
+}
+
+func takes(l ratelimit.WeightedLimiter) int64 {
+	return l.(ratelimit.StatsLimiter).Stats().Takes
+}
+
+// advanceUntil moves clk forward in steps until done is closed.
+func advanceUntil(clk interface{ Add(time.Duration) }, done <-chan struct{}, step time.Duration) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Millisecond):
+			clk.Add(step)
+		}
+	}
+}
+
+func TestConnWrite(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	start := clk.Now()
+	write := newLimiter(t, 10, ratelimit.WithSlack(4), ratelimit.WithClock(clk))
+	server, client := net.Pipe()
+	conn := NewConn(server, nil, write)
+	defer conn.Close()
+
+	data := bytes.Repeat([]byte("x"), 20)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, err := conn.Write(data)
+		assert.Equal(t, 20, n)
+		assert.NoError(t, err)
+		conn.Close()
+	}()
+
+	received := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(client)
+		received <- b
+	}()
+
+	advanceUntil(clk, done, 100*time.Millisecond)
+	assert.Equal(t, data, <-received)
+	assert.Equal(t, int64(20), takes(write))
+	assert.False(t, clk.Now().Before(start.Add(1900*time.Millisecond)), "20 bytes at 10 per second")
+}
+
+func TestConnRead(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	read := newLimiter(t, 10, ratelimit.WithSlack(4), ratelimit.WithClock(clk))
+	write := newLimiter(t, 10, ratelimit.WithClock(clk))
+	server, client := net.Pipe()
+	conn := NewConn(server, read, write)
+	defer conn.Close()
+
+	go func() {
+		client.Write(bytes.Repeat([]byte("x"), 12))
+		client.Close()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 100)
+		n, err := conn.Read(buf)
+		assert.Equal(t, 5, n, "should read at most a burst")
+		assert.NoError(t, err)
+
+		b, err := io.ReadAll(conn)
+		assert.Len(t, b, 7)
+		assert.NoError(t, err)
+	}()
+	advanceUntil(clk, done, 100*time.Millisecond)
+
+	assert.Equal(t, int64(12), takes(read))
+	assert.Zero(t, takes(write), "reads and writes should be limited independently")
+}
+
+func TestConnShared(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	shared := newLimiter(t, 100, ratelimit.WithClock(clk))
+
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		server, client := net.Pipe()
+		go io.Copy(io.Discard, client)
+		conn := NewConn(server, nil, newLimiter(t, 100, ratelimit.WithClock(clk)), WithSharedLimiter(shared))
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, conn := range conns {
+			_, err := conn.Write([]byte("abcde"))
+			assert.NoError(t, err)
+		}
+	}()
+	advanceUntil(clk, done, 10*time.Millisecond)
+	assert.Equal(t, int64(10), takes(shared), "should charge the shared limiter for all connections")
+}
+
+func TestConnClose(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	write := newLimiter(t, 1, ratelimit.WithoutSlack, ratelimit.WithClock(clk))
+	server, client := net.Pipe()
+	go io.Copy(io.Discard, client)
+	conn := NewConn(server, nil, write)
+
+	errs := make(chan error)
+	go func() {
+		_, err := conn.Write([]byte("ab"))
+		errs <- err
+	}()
+
+	// The second byte waits for a second, until the connection is closed.
+	require.Eventually(t, func() bool { return takes(write) == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, conn.Close())
+	err := <-errs
+	assert.True(t, errors.Is(err, net.ErrClosed), "unexpected error %v", err)
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package netlimit rate-limits network servers.
+//
+// NewListener limits how fast a listener accepts connections:
+//
+//	ln, err := net.Listen("tcp", ":8080")
+//	...
+//	ln = netlimit.NewListener(ln, ratelimit.New(100))
+//
+// NewConn limits the bandwidth of a connection, in bytes per second, with
+// bursts as large as reads and writes should be:
+//
+//	conn = netlimit.NewConn(conn,
+//		ratelimit.New(1<<20, ratelimit.WithBurst(32<<10)),
+//		ratelimit.New(64<<10, ratelimit.WithBurst(16<<10)))
+package netlimit // import "go.uber.org/ratelimit/netlimit"
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"go.uber.org/ratelimit"
+)
+
+// config configures a listener or a connection.
+type config struct {
+	wait  bool
+	keyed *ratelimit.KeyedLimiter[string]
+	key   func(net.Conn) string
+
+	// Only used by NewConn.
+	shared ratelimit.WeightedLimiter
+}
+
+// Option configures a listener or a connection.
+type Option interface {
+	apply(*config)
+}
+
+type waitOption bool
+
+func (o waitOption) apply(c *config) {
+	c.wait = bool(o)
+}
+
+// WithoutWaiting configures the listener to close connections over the
+// limit as soon as they're accepted, instead of waiting before accepting
+// them. It relies on the limiter implementing ratelimit.TryLimiter, as all
+// the limiters of the ratelimit package do; the listener waits on other
+// limiters.
+var WithoutWaiting Option = waitOption(false)
+
+type keyOption struct {
+	keyed *ratelimit.KeyedLimiter[string]
+	key   func(net.Conn) string
+}
+
+func (o keyOption) apply(c *config) {
+	c.keyed = o.keyed
+	c.key = o.key
+}
+
+// WithKey configures the listener to also limit each key separately, with
+// the limiters held by keyed. The key of a connection is extracted with
+// key, for example RemoteIP. Connections whose key is empty are only
+// limited by the limiter passed to NewListener.
+//
+// Keys are only known once connections are accepted, so a connection
+// waiting for the permission of its key delays the ones accepted after it.
+func WithKey(keyed *ratelimit.KeyedLimiter[string], key func(net.Conn) string) Option {
+	return keyOption{keyed: keyed, key: key}
+}
+
+// RemoteIP returns the IP address of the remote end of the connection,
+// without the port.
+func RemoteIP(c net.Conn) string {
+	addr := c.RemoteAddr()
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+type listener struct {
+	net.Listener
+	config
+	limiter ratelimit.Limiter
+
+	// ctx is done once the listener is closed, to stop waiting.
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewListener returns a listener that accepts connections from ln at the
+// rate allowed by l. l may be nil if WithKey is used, in which case only
+// the keys are limited.
+//
+// By default, Accept waits for a permission before accepting the next
+// connection, leaving the others in the backlog of the listener.
+func NewListener(ln net.Listener, l ratelimit.Limiter, opts ...Option) net.Listener {
+	c := config{wait: true}
+	for _, opt := range opts {
+		opt.apply(&c)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &listener{
+		Listener: ln,
+		config:   c,
+		limiter:  l,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Accept waits for a permission and the next connection, and returns the
+// connection. In the WithoutWaiting mode, it closes the connections over
+// the limit until it can return one.
+func (l *listener) Accept() (net.Conn, error) {
+	for {
+		if l.wait && l.limiter != nil {
+			if err := l.take(l.limiter); err != nil {
+				return nil, err
+			}
+		}
+
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if !l.wait && l.limiter != nil && !tryTake(l.limiter) {
+			conn.Close()
+			continue
+		}
+		if kl := l.keyLimiter(conn); kl != nil {
+			if !l.wait {
+				if !tryTake(kl) {
+					conn.Close()
+					continue
+				}
+			} else if err := l.take(kl); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, nil
+	}
+}
+
+// Close closes the listener, and stops any Accept waiting for a permission.
+func (l *listener) Close() error {
+	l.closeOnce.Do(func() {
+		l.cancel()
+		l.closeErr = l.Listener.Close()
+	})
+	return l.closeErr
+}
+
+// keyLimiter returns the limiter of the connection's key, if any.
+func (l *listener) keyLimiter(conn net.Conn) ratelimit.Limiter {
+	if l.keyed == nil {
+		return nil
+	}
+	if key := l.key(conn); key != "" {
+		return l.keyed.Get(key)
+	}
+	return nil
+}
+
+// take waits for a permission of rl, unless the listener is closed first.
+func (l *listener) take(rl ratelimit.Limiter) error {
+	cl, ok := rl.(ratelimit.ContextLimiter)
+	if !ok {
+		rl.Take()
+		return nil
+	}
+	if _, err := cl.TakeContext(l.ctx); err != nil {
+		return net.ErrClosed
+	}
+	return nil
+}
+
+// tryTake takes a permission of rl if it's available without waiting.
+func tryTake(rl ratelimit.Limiter) bool {
+	tl, ok := rl.(ratelimit.TryLimiter)
+	if !ok {
+		rl.Take()
+		return true
+	}
+	ok, _ = tl.TryTake()
+	return ok
+}
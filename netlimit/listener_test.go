@@ -0,0 +1,205 @@
+package netlimit
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/ratelimit"
+)
+
+func newMockClock() *clock.Mock {
+	// Some limiters use the zero time as "non-initialized" state.
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	return clk
+}
+
+// addr is a net.Addr with a fixed address.
+type addr string
+
+func (a addr) Network() string { return "pipe" }
+func (a addr) String() string  { return string(a) }
+
+// pipeConn is one end of a net.Pipe with a remote address.
+type pipeConn struct {
+	net.Conn
+	remote addr
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *pipeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+// pipeListener accepts the connections made with dial.
+type pipeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn, 16),
+		closed: make(chan struct{}),
+	}
+}
+
+// dial queues a connection from remote, and returns the server side.
+func (l *pipeListener) dial(remote string) *pipeConn {
+	server, client := net.Pipe()
+	go func() {
+		<-l.closed
+		client.Close()
+	}()
+	c := &pipeConn{Conn: server, remote: addr(remote), closed: make(chan struct{})}
+	l.conns <- c
+	return c
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return addr("listener") }
+
+func isClosed(c *pipeConn) bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestListenerWaiting(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	pl := newPipeListener()
+	ln := NewListener(pl, ratelimit.New(10, ratelimit.WithoutSlack, ratelimit.WithClock(clk)))
+	defer ln.Close()
+
+	c1, c2 := pl.dial("10.0.0.1:1"), pl.dial("10.0.0.2:1")
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	assert.Equal(t, c1, conn)
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := ln.Accept()
+		assert.NoError(t, err)
+		accepted <- conn
+	}()
+
+	clk.Add(50 * time.Millisecond)
+	select {
+	case <-accepted:
+		t.Fatal("shouldn't accept before the next permission")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clk.Add(50 * time.Millisecond)
+	assert.Equal(t, c2, <-accepted)
+}
+
+func TestListenerClose(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	pl := newPipeListener()
+	ln := NewListener(pl, ratelimit.New(10, ratelimit.WithoutSlack, ratelimit.WithClock(clk)))
+
+	pl.dial("10.0.0.1:1")
+	_, err := ln.Accept()
+	require.NoError(t, err)
+
+	errs := make(chan error)
+	go func() {
+		_, err := ln.Accept()
+		errs <- err
+	}()
+	require.NoError(t, ln.Close())
+	assert.True(t, errors.Is(<-errs, net.ErrClosed), "should stop waiting once closed")
+	assert.NoError(t, ln.Close(), "closing twice should be a no-op")
+}
+
+func TestListenerWithoutWaiting(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	pl := newPipeListener()
+	ln := NewListener(pl, ratelimit.New(10, ratelimit.WithoutSlack, ratelimit.WithClock(clk)), WithoutWaiting)
+	defer ln.Close()
+
+	c1, c2, c3 := pl.dial("10.0.0.1:1"), pl.dial("10.0.0.1:2"), pl.dial("10.0.0.1:3")
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	assert.Equal(t, c1, conn)
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := ln.Accept()
+		assert.NoError(t, err)
+		accepted <- conn
+	}()
+
+	// c2 and c3 are closed right away, and the listener waits for more.
+	require.Eventually(t, func() bool { return isClosed(c2) && isClosed(c3) }, time.Second, time.Millisecond)
+	clk.Add(100 * time.Millisecond)
+	c4 := pl.dial("10.0.0.1:4")
+	assert.Equal(t, c4, <-accepted)
+	assert.False(t, isClosed(c4))
+}
+
+func TestListenerPerRemoteIP(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	keyed, err := ratelimit.NewKeyed[string](10, ratelimit.WithoutSlack, ratelimit.WithClock(clk))
+	require.NoError(t, err)
+	pl := newPipeListener()
+	ln := NewListener(pl, nil, WithoutWaiting, WithKey(keyed, RemoteIP))
+	defer ln.Close()
+
+	c1, c2, c3 := pl.dial("10.0.0.1:1"), pl.dial("10.0.0.1:2"), pl.dial("10.0.0.2:1")
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	assert.Equal(t, c1, conn)
+	conn, err = ln.Accept()
+	require.NoError(t, err)
+	assert.Equal(t, c3, conn, "other IPs should be limited separately")
+	assert.True(t, isClosed(c2))
+	assert.Equal(t, 2, keyed.Len())
+}
+
+func TestRemoteIP(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{remote: "10.0.0.1:1234", want: "10.0.0.1"},
+		{remote: "[::1]:1234", want: "::1"},
+		{remote: "pipe", want: "pipe"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, RemoteIP(&pipeConn{remote: addr(tt.remote)}), tt.remote)
+	}
+}
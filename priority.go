@@ -0,0 +1,198 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// PriorityLimiter paces permissions like the limiters returned by New, and
+// serves its waiting callers by priority: the next permission goes to a
+// caller of the highest priority waiting, rather than to whichever caller
+// gets it first. Callers of the same priority are served in the order they
+// came.
+//
+// Priorities can be configured WithReserved to get a share of the
+// permissions while higher priorities are waiting, so that they aren't
+// starved.
+type PriorityLimiter struct {
+	dispatcher
+	reserved []float64
+
+	// queues holds the waiting callers of every priority, oldest first.
+	queues []list.List
+	// credits are the permissions owed to each priority for its reserved
+	// share, which it gets once they add up to one.
+	credits []float64
+}
+
+// NewPriority returns a PriorityLimiter that issues permissions at the
+// given rate, which must be positive and finite, to callers of priorities
+// 0 to priorities-1, the highest.
+func NewPriority(rate Rate, priorities int, opts ...Option) (*PriorityLimiter, error) {
+	config := buildConfig(opts)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkRate(rate); err != nil {
+		return nil, err
+	}
+	if rate >= Inf {
+		return nil, errors.New("ratelimit: rate of a priority limiter must be finite")
+	}
+	if err := checkRateLow(rate, config.per); err != nil {
+		return nil, err
+	}
+	if priorities < 1 {
+		return nil, fmt.Errorf("ratelimit: number of priorities must be positive, got %d", priorities)
+	}
+
+	reserved := make([]float64, priorities)
+	total := 0.0
+	for p, fraction := range config.reserved {
+		if p < 0 || p >= priorities {
+			return nil, fmt.Errorf("ratelimit: reserved priority %d must be between 0 and %d", p, priorities-1)
+		}
+		if !(fraction >= 0 && fraction <= 1) {
+			return nil, fmt.Errorf("ratelimit: reserved fraction must be between 0 and 1, got %v", fraction)
+		}
+		reserved[p] = fraction
+		total += fraction
+	}
+	if total > 1 {
+		return nil, fmt.Errorf("ratelimit: reserved fractions must add up to at most 1, got %v", total)
+	}
+
+	p := &PriorityLimiter{
+		dispatcher: newDispatcher(rate, config, opts),
+		reserved:   reserved,
+		queues:     make([]list.List, priorities),
+		credits:    make([]float64, priorities),
+	}
+	p.next = p.nextWaiter
+	return p, nil
+}
+
+// TakePriority blocks until a permission is issued to the caller, or
+// returns the error of ctx if it's done first. It returns an error if the
+// priority is out of range.
+//
+// Unlike TakeContext, it can't tell ahead of time whether the wait exceeds
+// the deadline of ctx, as that depends on the callers that come next.
+func (p *PriorityLimiter) TakePriority(ctx context.Context, priority int) (time.Time, error) {
+	if priority < 0 || priority >= len(p.queues) {
+		return time.Time{}, fmt.Errorf("ratelimit: priority must be between 0 and %d, got %d", len(p.queues)-1, priority)
+	}
+	return p.take(ctx, func(w *waiter) func() {
+		elem := p.queues[priority].PushBack(w)
+		return func() { p.queues[priority].Remove(elem) }
+	})
+}
+
+// nextWaiter removes the waiter that gets the next permission from the
+// queues, if any. It must be called with the lock held.
+func (p *PriorityLimiter) nextWaiter() *waiter {
+	// Priorities are owed their reserved share of the permissions issued
+	// while they wait.
+	for prio := range p.queues {
+		if p.queues[prio].Len() == 0 {
+			p.credits[prio] = 0
+		} else {
+			p.credits[prio] += p.reserved[prio]
+		}
+	}
+
+	chosen := -1
+	for prio := len(p.queues) - 1; prio >= 0; prio-- {
+		if p.queues[prio].Len() == 0 {
+			continue
+		}
+		if chosen < 0 {
+			chosen = prio
+		}
+		// Adding up fractions may fall short of one by a rounding error.
+		if p.credits[prio] >= 1-1e-9 {
+			chosen = prio
+			break
+		}
+	}
+	if chosen < 0 {
+		return nil
+	}
+
+	p.credits[chosen] = math.Max(0, p.credits[chosen]-1)
+	return p.queues[chosen].Remove(p.queues[chosen].Front()).(*waiter)
+}
+
+// Class returns a ContextLimiter whose Take and TakeContext take
+// permissions at the given priority, for code that expects a Limiter.
+// Take panics if the priority is out of range.
+func (p *PriorityLimiter) Class(priority int) ContextLimiter {
+	return priorityClass{limiter: p, priority: priority}
+}
+
+// Stats returns a snapshot of the limiter.
+func (p *PriorityLimiter) Stats() Stats {
+	return p.rate.Stats()
+}
+
+type priorityClass struct {
+	limiter  *PriorityLimiter
+	priority int
+}
+
+func (c priorityClass) Take() time.Time {
+	t, err := c.TakeContext(context.Background())
+	if err != nil {
+		// The context is never done, so the priority is out of range.
+		panic(err)
+	}
+	return t
+}
+
+func (c priorityClass) TakeContext(ctx context.Context) (time.Time, error) {
+	return c.limiter.TakePriority(ctx, c.priority)
+}
+
+type reservedOption struct {
+	priority int
+	fraction float64
+}
+
+func (o reservedOption) apply(c *config) {
+	if c.reserved == nil {
+		c.reserved = make(map[int]float64)
+	}
+	c.reserved[o.priority] = o.fraction
+}
+
+// WithReserved configures a PriorityLimiter to reserve a fraction of the
+// permissions to the given priority: while it has waiting callers, it
+// gets at least that share of the permissions even if higher priorities
+// are waiting too. It has no effect on other limiters.
+func WithReserved(priority int, fraction float64) Option {
+	return reservedOption{priority: priority, fraction: fraction}
+}
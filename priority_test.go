@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForPriorityWaiters waits until n callers wait for a permission of p.
+func waitForPriorityWaiters(t *testing.T, p *PriorityLimiter, n int) {
+	require.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.waiting == n
+	}, time.Second, time.Millisecond)
+}
+
+// takeInOrder starts a caller of each of the priorities, one after
+// another, and returns the channel their priorities are sent to once they
+// get a permission.
+func takeInOrder(t *testing.T, p *PriorityLimiter, priorities ...int) <-chan int {
+	got := make(chan int, len(priorities))
+	for i, prio := range priorities {
+		prio := prio
+		go func() {
+			_, err := p.TakePriority(context.Background(), prio)
+			assert.NoError(t, err)
+			got <- prio
+		}()
+		waitForPriorityWaiters(t, p, i+1)
+	}
+	return got
+}
+
+// receiveAdvancing advances clk until got receives a value.
+func receiveAdvancing[T any](clk *clock.Mock, got <-chan T) T {
+	for {
+		select {
+		case v := <-got:
+			return v
+		case <-time.After(time.Millisecond):
+			clk.Add(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestNewPriorityInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		msg        string
+		rate       Rate
+		priorities int
+		opts       []Option
+		wantErr    string
+	}{
+		{
+			msg: "zero rate", rate: 0, priorities: 2,
+			wantErr: "ratelimit: rate must be positive, got 0",
+		},
+		{
+			msg: "infinite rate", rate: Inf, priorities: 2,
+			wantErr: "ratelimit: rate of a priority limiter must be finite",
+		},
+		{
+			msg: "no priorities", rate: 10, priorities: 0,
+			wantErr: "ratelimit: number of priorities must be positive, got 0",
+		},
+		{
+			msg: "reserved out of range", rate: 10, priorities: 2,
+			opts:    []Option{WithReserved(2, 0.1)},
+			wantErr: "ratelimit: reserved priority 2 must be between 0 and 1",
+		},
+		{
+			msg: "negative fraction", rate: 10, priorities: 2,
+			opts:    []Option{WithReserved(0, -0.1)},
+			wantErr: "ratelimit: reserved fraction must be between 0 and 1, got -0.1",
+		},
+		{
+			msg: "fractions over one", rate: 10, priorities: 2,
+			opts:    []Option{WithReserved(0, 0.6), WithReserved(1, 0.6)},
+			wantErr: "ratelimit: reserved fractions must add up to at most 1, got 1.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			p, err := NewPriority(tt.rate, tt.priorities, tt.opts...)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, p)
+		})
+	}
+}
+
+func TestPriorityOutOfRange(t *testing.T) {
+	t.Parallel()
+	p, err := NewPriority(10, 2)
+	require.NoError(t, err)
+
+	_, err = p.TakePriority(context.Background(), 2)
+	assert.EqualError(t, err, "ratelimit: priority must be between 0 and 1, got 2")
+	assert.Panics(t, func() { p.Class(-1).Take() })
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	p, err := NewPriority(10, 3, WithoutSlack, WithClock(clk), WithStats())
+	require.NoError(t, err)
+
+	// Nobody waits, so the first permission is issued right away.
+	issuedAt, err := p.TakePriority(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, clk.Now(), issuedAt)
+
+	got := takeInOrder(t, p, 0, 1, 2, 1)
+	for _, want := range []int{2, 1, 1, 0} {
+		assert.Equal(t, want, receiveAdvancing(clk, got))
+	}
+	assert.Equal(t, int64(5), p.Stats().Takes)
+}
+
+func TestPriorityClass(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	p, err := NewPriority(10, 2, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	p.Class(1).Take()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.Class(1).TakeContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestPriorityReserved(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	p, err := NewPriority(10, 2, WithoutSlack, WithClock(clk), WithReserved(0, 0.25))
+	require.NoError(t, err)
+	p.Class(1).Take()
+
+	got := takeInOrder(t, p, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1)
+	// The low priority gets one permission in four while it waits.
+	for _, want := range []int{1, 1, 1, 0, 1, 1, 1, 0, 1, 1} {
+		assert.Equal(t, want, receiveAdvancing(clk, got))
+	}
+}
+
+func TestPriorityCancel(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	p, err := NewPriority(10, 2, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+	p.Class(1).Take()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := p.TakePriority(ctx, 1)
+		done <- err
+	}()
+	waitForPriorityWaiters(t, p, 1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	waitForPriorityWaiters(t, p, 0)
+
+	// The permission the canceled caller waited for goes to the next one.
+	got := takeInOrder(t, p, 0)
+	assert.Equal(t, 0, receiveAdvancing(clk, got))
+}
@@ -21,6 +21,10 @@
 package ratelimit // import "go.uber.org/ratelimit"
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
 	"time"
 
 	"github.com/benbjohnson/clock"
@@ -37,12 +41,100 @@ type Limiter interface {
 	Take() time.Time
 }
 
+// ContextLimiter is a Limiter whose waits can be abandoned.
+//
+// All limiters returned by this package implement ContextLimiter.
+type ContextLimiter interface {
+	Limiter
+
+	// TakeContext is like Take, but returns early with an error if ctx is
+	// done before the permission is issued. If ctx has a deadline that the
+	// wait would exceed, TakeContext fails immediately with
+	// ErrWaitExceedsDeadline. A permission that is not used because of
+	// either error is given back to the limiter.
+	TakeContext(ctx context.Context) (time.Time, error)
+}
+
+// TryLimiter is a Limiter that can reject work instead of waiting.
+//
+// All limiters returned by this package implement TryLimiter.
+type TryLimiter interface {
+	Limiter
+
+	// TryTake takes a permission only if it is available without waiting.
+	// Otherwise, it returns false and how long until the next permission
+	// would be issued, without taking it.
+	TryTake() (ok bool, retryAfter time.Duration)
+}
+
+// WeightedLimiter is a Limiter for operations that cost several permissions,
+// such as batches or byte transfers.
+//
+// Taking n permissions at once costs the same as taking them one after
+// another, and the n-th of them decides when the call returns. When n is
+// larger than the accumulated slack, the caller waits for the remainder
+// instead of failing. Values of n below 1 take nothing.
+//
+// All limiters returned by this package implement WeightedLimiter.
+type WeightedLimiter interface {
+	Limiter
+
+	// TakeN is like Take, for n permissions.
+	TakeN(n int) time.Time
+	// TryTakeN is like TryTake, for n permissions. It takes either all of
+	// them or none. As it doesn't wait, it can't take more permissions
+	// than the burst, slack+1, and always fails for such n: retryAfter is
+	// then how long TakeN would wait for them, not a time it succeeds at.
+	TryTakeN(n int) (ok bool, retryAfter time.Duration)
+	// TakeNContext is like TakeContext, for n permissions.
+	TakeNContext(ctx context.Context, n int) (time.Time, error)
+}
+
+// ReservingLimiter is a Limiter that can hand out permissions ahead of
+// time, leaving the wait to the caller.
+//
+// All limiters returned by this package implement ReservingLimiter.
+type ReservingLimiter interface {
+	Limiter
+
+	// Reserve takes n permissions without waiting for them. The returned
+	// Reservation tells how long to wait before acting, and can give the
+	// permissions back if they end up unused.
+	Reserve(n int) *Reservation
+}
+
+// AdjustableLimiter is a Limiter whose limits can be changed while it's in
+// use. Changes apply from the next permission on; permissions that were
+// already issued or are being waited for keep their times.
+//
+// All limiters returned by New and NewWithRate implement AdjustableLimiter,
+// except for those with a zero or infinite rate.
+type AdjustableLimiter interface {
+	Limiter
+
+	// SetRate changes the number of permissions per time window, keeping
+	// the window and the slack as they are. The rate must be positive.
+	SetRate(rate Rate) error
+	// SetLimit changes both the number of permissions and the time window
+	// they are spread across, like NewWithRate(rate, Per(per)) does.
+	SetLimit(rate Rate, per time.Duration) error
+	// SetSlack changes the number of unspent permissions the limiter
+	// accumulates for future bursts, like WithSlack does.
+	SetSlack(slack int) error
+}
+
+// ErrWaitExceedsDeadline is returned by TakeContext when the permission
+// would be issued after the context's deadline. It matches
+// context.DeadlineExceeded with errors.Is.
+var ErrWaitExceedsDeadline = fmt.Errorf("ratelimit: wait would exceed context deadline: %w", context.DeadlineExceeded)
+
 // Clock is the minimum necessary interface to instantiate a rate limiter with
 // a clock or mock clock, compatible with clocks created using
 // github.com/andres-erbsen/clock.
 type Clock interface {
 	Now() time.Time
 	Sleep(time.Duration)
+	After(time.Duration) <-chan time.Time
 }
 
 // config configures a limiter.
@@ -50,11 +142,99 @@ type config struct {
 	clock Clock
 	slack int
 	per   time.Duration
+	stats bool
+	// hasBurst is set if the slack was configured WithBurst.
+	hasBurst bool
+	// initialTokens is only used if hasInitialTokens is set.
+	initialTokens    int
+	hasInitialTokens bool
+
+	spacingMode spacingMode
+	jitter      float64
+	randSource  rand.Source
+
+	warmupDuration    time.Duration
+	warmupFraction    float64
+	warmupExponential bool
+
+	transition time.Duration
+
+	// Only used by KeyedLimiter.
+	idleTTL time.Duration
+	maxKeys int
+
+	// Only used by AIMDLimiter.
+	increase Rate
+	decrease float64
+
+	// Only used by PriorityLimiter.
+	reserved map[int]float64
+}
+
+// counters returns the counters to collect stats, if configured.
+func (c config) counters() *counters {
+	if !c.stats {
+		return nil
+	}
+	return new(counters)
 }
 
 // New returns a Limiter that will limit to the given RPS.
+//
+// A zero rate denies all permissions, see NewWithRate. New panics if the
+// rate or the options are invalid.
 func New(rate int, opts ...Option) Limiter {
-	return newAtomicInt64Based(rate, opts...)
+	l, err := NewWithRate(Rate(rate), opts...)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// NewWithRate returns a Limiter that will limit to the given, possibly
+// fractional, rate. It returns an error if the rate or the options are
+// invalid.
+//
+// A zero rate returns a Limiter that never issues permissions, and Inf
+// returns one that doesn't limit at all, like NewUnlimited.
+//
+//	NewWithRate(1.5)                    // 3 per 2 seconds
+//	NewWithRate(0.5, Per(time.Minute))  // 1 per 2 minutes
+func NewWithRate(rate Rate, opts ...Option) (Limiter, error) {
+	l, err := newLimiter(rate, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// limiter is implemented by all limiters returned by NewWithRate.
+type limiter interface {
+	ContextLimiter
+	TryLimiter
+	WeightedLimiter
+	ReservingLimiter
+	StatsLimiter
+}
+
+func newLimiter(rate Rate, opts ...Option) (limiter, error) {
+	config := buildConfig(opts)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case math.IsNaN(float64(rate)) || rate < 0:
+		return nil, fmt.Errorf("ratelimit: rate must not be negative, got %v", float64(rate))
+	case rate == 0:
+		return denyAll{}, nil
+	case rate >= Inf:
+		return unlimited{}, nil
+	}
+	if err := checkRateLow(rate, config.per); err != nil {
+		return nil, err
+	}
+	return newAtomicInt64Based(rate, opts...), nil
 }
 
 // buildConfig combines defaults with options.
@@ -63,6 +243,9 @@ func buildConfig(opts []Option) config {
 		clock: clock.New(),
 		slack: 10,
 		per:   time.Second,
+
+		increase: 1,
+		decrease: 0.5,
 	}
 
 	for _, opt := range opts {
@@ -71,6 +254,26 @@ func buildConfig(opts []Option) config {
 	return c
 }
 
+// validate reports options that don't make sense.
+func (c config) validate() error {
+	if err := checkPer(c.per); err != nil {
+		return err
+	}
+	if c.hasBurst && c.slack < 0 {
+		return fmt.Errorf("ratelimit: burst must be positive, got %d", c.slack+1)
+	}
+	if err := checkSlack(c.slack); err != nil {
+		return err
+	}
+	if c.hasInitialTokens && (c.initialTokens < 0 || c.initialTokens > c.slack+1) {
+		return fmt.Errorf("ratelimit: initial tokens must be between 0 and the burst of %d, got %d", c.slack+1, c.initialTokens)
+	}
+	if err := checkJitter(c.jitter); err != nil {
+		return err
+	}
+	return checkWarmup(c.warmupDuration, c.warmupFraction)
+}
+
 // Option configures a Limiter.
 type Option interface {
 	apply(*config)
@@ -94,6 +297,7 @@ type slackOption int
 
 func (o slackOption) apply(c *config) {
 	c.slack = int(o)
+	c.hasBurst = false
 }
 
 // WithoutSlack configures the limiter to be strict and not to accumulate
@@ -107,6 +311,45 @@ func WithSlack(slack int) Option {
 	return slackOption(slack)
 }
 
+// WithBurst configures the limiter as a token bucket that holds up to n
+// tokens, one per permission. Tokens are added at the limiter's rate, and
+// a limiter that was idle long enough issues n permissions at once.
+//
+// The slack of a limiter is the number of tokens it holds beyond the one
+// that the next permission takes, so WithBurst(n) is the same as
+// WithSlack(n-1), and WithoutSlack the same as WithBurst(1).
+func WithBurst(n int) Option {
+	return burstOption(n)
+}
+
+type burstOption int
+
+func (o burstOption) apply(c *config) {
+	c.slack = int(o) - 1
+	c.hasBurst = true
+}
+
+type initialTokensOption int
+
+func (o initialTokensOption) apply(c *config) {
+	c.initialTokens = int(o)
+	c.hasInitialTokens = true
+}
+
+// WithInitialTokens configures the number of tokens the bucket holds when
+// the limiter is created, which is at most its burst. Tokens accumulate
+// from then on, even if the limiter isn't used.
+//
+// By default, the bucket starts with a single token when the limiter is
+// first used, which is why the first Take doesn't wait, and slack only
+// accumulates after that. WithInitialTokens(0) makes the first Take wait
+// like the others, and WithInitialTokens(n) with the burst n allows a
+// full burst right away. It's supported by the limiters returned by New
+// and NewWithRate.
+func WithInitialTokens(n int) Option {
+	return initialTokensOption(n)
+}
+
 type perOption time.Duration
 
 func (p perOption) apply(c *config) {
@@ -133,3 +376,182 @@ func NewUnlimited() Limiter {
 func (unlimited) Take() time.Time {
 	return time.Now()
 }
+
+func (unlimited) TakeContext(ctx context.Context) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Now(), nil
+}
+
+func (unlimited) TryTake() (bool, time.Duration) {
+	return true, 0
+}
+
+func (unlimited) TakeN(int) time.Time {
+	return time.Now()
+}
+
+func (u unlimited) TryTakeN(int) (bool, time.Duration) {
+	return u.TryTake()
+}
+
+func (u unlimited) TakeNContext(ctx context.Context, _ int) (time.Time, error) {
+	return u.TakeContext(ctx)
+}
+
+func (unlimited) Reserve(int) *Reservation {
+	return &Reservation{ok: true, issuedAt: time.Now()}
+}
+
+func (unlimited) Stats() Stats {
+	now := time.Now()
+	return Stats{Rate: Inf, Available: math.MaxInt, NextPermission: now}
+}
+
+// denyAll is a Limiter that never issues permissions.
+type denyAll struct{}
+
+// Take blocks forever.
+func (denyAll) Take() time.Time {
+	select {}
+}
+
+func (d denyAll) TakeContext(ctx context.Context) (time.Time, error) {
+	return d.TakeNContext(ctx, 1)
+}
+
+func (denyAll) TryTake() (bool, time.Duration) {
+	return false, math.MaxInt64
+}
+
+func (d denyAll) TakeN(n int) time.Time {
+	if n < 1 {
+		return time.Now()
+	}
+	return d.Take()
+}
+
+func (d denyAll) TryTakeN(n int) (bool, time.Duration) {
+	if n < 1 {
+		return true, 0
+	}
+	return d.TryTake()
+}
+
+func (denyAll) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+	if n < 1 {
+		return time.Now(), nil
+	}
+	return time.Time{}, waitForever(ctx)
+}
+
+func (denyAll) Reserve(n int) *Reservation {
+	return &Reservation{ok: n < 1, issuedAt: time.Now()}
+}
+
+// Stats reports no permissions, ever.
+func (denyAll) Stats() Stats {
+	return Stats{}
+}
+
+// reserver is implemented by the limiters in this package to share
+// the logic of the variants of Take.
+type reserver interface {
+	// reserve claims the next n permissions at now, unless they would be
+	// issued more than maxWait later. It returns the time the last of them
+	// is (or would have been) issued at.
+	reserve(now time.Time, n int, maxWait time.Duration) (time.Time, bool)
+	// refund gives back n reserved permissions that were not used.
+	refund(n int)
+	// record counts n permissions that were waited for d.
+	record(n int, d time.Duration)
+}
+
+// takeN implements TakeN on top of a reserver.
+func takeN(clock Clock, r reserver, n int) time.Time {
+	now := clock.Now()
+	if n < 1 {
+		return now
+	}
+
+	issuedAt, _ := r.reserve(now, n, math.MaxInt64)
+	d := issuedAt.Sub(now)
+	if d > 0 {
+		clock.Sleep(d)
+	}
+	r.record(n, d)
+	return issuedAt
+}
+
+// takeContext implements TakeNContext on top of a reserver.
+func takeContext(ctx context.Context, clock Clock, r reserver, n int) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	now := clock.Now()
+	if n < 1 {
+		return now, nil
+	}
+
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = deadline.Sub(now)
+	}
+
+	issuedAt, ok := r.reserve(now, n, maxWait)
+	if !ok {
+		return time.Time{}, waitForever(ctx)
+	}
+	d := issuedAt.Sub(now)
+	if err := sleepContext(ctx, clock, d); err != nil {
+		r.refund(n)
+		return time.Time{}, err
+	}
+	r.record(n, d)
+	return issuedAt, nil
+}
+
+// waitForever is left to callers whose permissions are never issued, or
+// too far ahead to be tracked: it returns ErrWaitExceedsDeadline if ctx has
+// a deadline, and ctx.Err() once it's done otherwise.
+func waitForever(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); ok {
+		return ErrWaitExceedsDeadline
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// tryTake implements TryTakeN on top of a reserver.
+func tryTake(clock Clock, r reserver, n int) (bool, time.Duration) {
+	if n < 1 {
+		return true, 0
+	}
+
+	now := clock.Now()
+	issuedAt, ok := r.reserve(now, n, 0)
+	if !ok {
+		return false, issuedAt.Sub(now)
+	}
+	r.record(n, 0)
+	return true, 0
+}
+
+// sleepContext is like Clock.Sleep, but returns early with ctx.Err()
+// if ctx is done first.
+func sleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
@@ -1,6 +1,9 @@
 package ratelimit
 
 import (
+	"context"
+	"errors"
+	"math"
 	"sync"
 	"testing"
 	"time"
@@ -13,7 +16,7 @@ import (
 
 type testRunner interface {
 	// createLimiter builds a limiter with given options.
-	createLimiter(int, ...Option) Limiter
+	createLimiter(Rate, ...Option) Limiter
 	// takeOnceAfter attempts to Take at a specific time.
 	takeOnceAfter(time.Duration, Limiter)
 	// startTaking tries to Take() on passed in limiters in a loop/goroutine.
@@ -32,7 +35,7 @@ type runnerImpl struct {
 	t *testing.T
 
 	clock       *clock.Mock
-	constructor func(int, ...Option) Limiter
+	constructor func(Rate, ...Option) Limiter
 	count       atomic.Int32
 	// maxDuration is the time we need to move into the future for a test.
 	// It's populated automatically based on assertCountAt/afterFunc.
@@ -44,23 +47,23 @@ type runnerImpl struct {
 func runTest(t *testing.T, fn func(testRunner)) {
 	impls := []struct {
 		name        string
-		constructor func(int, ...Option) Limiter
+		constructor func(Rate, ...Option) Limiter
 	}{
 		{
 			name: "mutex",
-			constructor: func(rate int, opts ...Option) Limiter {
+			constructor: func(rate Rate, opts ...Option) Limiter {
 				return newMutexBased(rate, opts...)
 			},
 		},
 		{
 			name: "atomic",
-			constructor: func(rate int, opts ...Option) Limiter {
+			constructor: func(rate Rate, opts ...Option) Limiter {
 				return newAtomicBased(rate, opts...)
 			},
 		},
 		{
 			name: "atomic_int64",
-			constructor: func(rate int, opts ...Option) Limiter {
+			constructor: func(rate Rate, opts ...Option) Limiter {
 				return newAtomicInt64Based(rate, opts...)
 			},
 		},
@@ -88,7 +91,7 @@ func runTest(t *testing.T, fn func(testRunner)) {
 }
 
 // createLimiter builds a limiter with given options.
-func (r *runnerImpl) createLimiter(rate int, opts ...Option) Limiter {
+func (r *runnerImpl) createLimiter(rate Rate, opts ...Option) Limiter {
 	opts = append(opts, WithClock(r.clock))
 	return r.constructor(rate, opts...)
 }
@@ -170,6 +173,143 @@ func TestUnlimited(t *testing.T) {
 	assert.Condition(t, func() bool { return time.Since(now) < 1*time.Millisecond }, "no artificial delay")
 }
 
+func TestUnlimitedTakeContext(t *testing.T) {
+	t.Parallel()
+	rl := NewUnlimited().(ContextLimiter)
+
+	_, err := rl.TakeContext(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = rl.TakeContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestUnlimitedTryTake(t *testing.T) {
+	t.Parallel()
+	rl := NewUnlimited().(TryLimiter)
+	for i := 0; i < 1000; i++ {
+		ok, retryAfter := rl.TryTake()
+		assert.True(t, ok)
+		assert.Zero(t, retryAfter)
+	}
+}
+
+func TestUnlimitedTakeN(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	rl := NewUnlimited().(WeightedLimiter)
+	for i := 0; i < 1000; i++ {
+		rl.TakeN(1000)
+	}
+	assert.Condition(t, func() bool { return time.Since(now) < 1*time.Millisecond }, "no artificial delay")
+}
+
+func TestUnlimitedReserve(t *testing.T) {
+	t.Parallel()
+	rl := NewUnlimited().(ReservingLimiter)
+
+	res := rl.Reserve(1000)
+	assert.True(t, res.OK())
+	assert.Zero(t, res.Delay())
+	res.Cancel()
+}
+
+func TestNewWithRate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		msg     string
+		rate    Rate
+		opts    []Option
+		wantErr string
+	}{
+		{msg: "fractional", rate: 1.5},
+		{msg: "sub-one", rate: 0.25, opts: []Option{Per(time.Minute)}},
+		{msg: "zero", rate: 0},
+		{msg: "inf", rate: Inf},
+		{msg: "math inf", rate: Rate(math.Inf(1))},
+		{msg: "negative", rate: -1, wantErr: "ratelimit: rate must not be negative, got -1"},
+		{msg: "NaN", rate: Rate(math.NaN()), wantErr: "ratelimit: rate must not be negative, got NaN"},
+		{msg: "zero per", rate: 1, opts: []Option{Per(0)}, wantErr: "ratelimit: per must be positive, got 0s"},
+		{msg: "negative slack", rate: 1, opts: []Option{WithSlack(-1)}, wantErr: "ratelimit: slack must not be negative, got -1"},
+		{msg: "too low", rate: 1e-10, wantErr: "ratelimit: rate is too low, got 1e-10 per 1s"},
+		{msg: "too low per", rate: 1e-6, opts: []Option{Per(24 * time.Hour)}, wantErr: "ratelimit: rate is too low, got 1e-06 per 24h0m0s"},
+		{msg: "lowest", rate: 1e-9},
+		{msg: "burst", rate: 1, opts: []Option{WithBurst(5), WithInitialTokens(5)}},
+		{msg: "zero burst", rate: 1, opts: []Option{WithBurst(0)}, wantErr: "ratelimit: burst must be positive, got 0"},
+		{msg: "negative burst", rate: 1, opts: []Option{WithBurst(-2)}, wantErr: "ratelimit: burst must be positive, got -2"},
+		{msg: "slack after burst", rate: 1, opts: []Option{WithBurst(0), WithSlack(-1)}, wantErr: "ratelimit: slack must not be negative, got -1"},
+		{msg: "negative initial tokens", rate: 1, opts: []Option{WithInitialTokens(-1)}, wantErr: "ratelimit: initial tokens must be between 0 and the burst of 11, got -1"},
+		{msg: "initial tokens over burst", rate: 1, opts: []Option{WithBurst(2), WithInitialTokens(3)}, wantErr: "ratelimit: initial tokens must be between 0 and the burst of 2, got 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			rl, err := NewWithRate(tt.rate, tt.opts...)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, rl)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, rl)
+		})
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { New(-1) })
+	assert.Panics(t, func() { New(1, Per(-time.Second)) })
+	assert.NotPanics(t, func() { New(0) })
+}
+
+func TestNewWithRateInf(t *testing.T) {
+	t.Parallel()
+	rl, err := NewWithRate(Inf)
+	assert.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		ok, _ := rl.(TryLimiter).TryTake()
+		assert.True(t, ok)
+	}
+}
+
+func TestDenyAll(t *testing.T) {
+	t.Parallel()
+	rl, err := NewWithRate(0)
+	assert.NoError(t, err)
+
+	ok, retryAfter := rl.(TryLimiter).TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, time.Duration(math.MaxInt64), retryAfter)
+
+	ok, _ = rl.(WeightedLimiter).TryTakeN(0)
+	assert.True(t, ok, "nothing to take")
+
+	res := rl.(ReservingLimiter).Reserve(1)
+	assert.False(t, res.OK())
+	assert.Equal(t, time.Duration(math.MaxInt64), res.Delay())
+	res.Cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_, err = rl.(ContextLimiter).TakeContext(ctx)
+	assert.Equal(t, ErrWaitExceedsDeadline, err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go cancel()
+	_, err = rl.(ContextLimiter).TakeContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestUnlimitedStats(t *testing.T) {
+	t.Parallel()
+	stats := NewUnlimited().(StatsLimiter).Stats()
+	assert.Equal(t, Inf, stats.Rate)
+	assert.Equal(t, math.MaxInt, stats.Available)
+}
+
 func TestRateLimiter(t *testing.T) {
 	t.Parallel()
 	runTest(t, func(r testRunner) {
@@ -388,3 +528,567 @@ func TestSlack(t *testing.T) {
 		})
 	}
 }
+
+func TestTakeContext(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(ContextLimiter)
+		clk := r.getClock()
+		start, err := rl.TakeContext(context.Background())
+		assert.NoError(t, err)
+
+		results := make(chan time.Time)
+		var startWg sync.WaitGroup
+		startWg.Add(1)
+		go func() {
+			startWg.Done()
+			ts, err := rl.TakeContext(context.Background())
+			assert.NoError(t, err)
+			results <- ts
+		}()
+
+		startWg.Wait()
+		clk.Add(100 * time.Millisecond)
+		assert.Equal(t, start.Add(100*time.Millisecond), <-results, "should wait like Take")
+	})
+}
+
+func TestTakeContextCancel(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(ContextLimiter)
+		clk := r.getClock()
+		start, err := rl.TakeContext(context.Background())
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error)
+		go func() {
+			_, err := rl.TakeContext(ctx)
+			errs <- err
+		}()
+
+		clk.Add(50 * time.Millisecond)
+		cancel()
+		assert.Equal(t, context.Canceled, <-errs)
+
+		// The cancelled permission is given back, so the next one
+		// is still issued 100ms after the first.
+		clk.Add(50 * time.Millisecond)
+		ts, err := rl.TakeContext(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, start.Add(100*time.Millisecond), ts)
+
+		_, err = rl.TakeContext(ctx)
+		assert.Equal(t, context.Canceled, err, "done context should fail fast")
+	})
+}
+
+func TestTakeContextDeadline(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(ContextLimiter)
+		clk := r.getClock()
+		start, err := rl.TakeContext(context.Background())
+		assert.NoError(t, err)
+
+		ctx, cancel := clk.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = rl.TakeContext(ctx)
+		assert.Equal(t, ErrWaitExceedsDeadline, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "should match context.DeadlineExceeded")
+
+		// Nothing was reserved by the failed call.
+		clk.Add(100 * time.Millisecond)
+		ts, err := rl.TakeContext(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, start.Add(100*time.Millisecond), ts)
+	})
+}
+
+func TestTryTake(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(TryLimiter)
+		clk := r.getClock()
+
+		ok, retryAfter := rl.TryTake()
+		assert.True(t, ok, "first take is allowed")
+		assert.Zero(t, retryAfter)
+
+		ok, retryAfter = rl.TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+		clk.Add(40 * time.Millisecond)
+		ok, retryAfter = rl.TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 60*time.Millisecond, retryAfter, "rejections shouldn't take permissions")
+
+		clk.Add(60 * time.Millisecond)
+		ok, _ = rl.TryTake()
+		assert.True(t, ok)
+	})
+}
+
+func TestTryTakeSlack(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithSlack(2)).(TryLimiter)
+		clk := r.getClock()
+
+		ok, _ := rl.TryTake()
+		assert.True(t, ok)
+
+		// After a long pause, the slack and the regular permission
+		// are available right away.
+		clk.Add(time.Second)
+		for i := 0; i < 3; i++ {
+			ok, _ := rl.TryTake()
+			assert.True(t, ok, "take %d should be allowed", i)
+		}
+
+		ok, retryAfter := rl.TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 100*time.Millisecond, retryAfter)
+	})
+}
+
+func TestBurst(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		// A burst of 3 is a slack of 2.
+		rl := r.createLimiter(10, WithBurst(3)).(TryLimiter)
+		clk := r.getClock()
+
+		ok, _ := rl.TryTake()
+		assert.True(t, ok)
+
+		clk.Add(time.Second)
+		for i := 0; i < 3; i++ {
+			ok, _ := rl.TryTake()
+			assert.True(t, ok, "take %d should be allowed", i)
+		}
+
+		ok, retryAfter := rl.TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 100*time.Millisecond, retryAfter)
+	})
+}
+
+func TestInitialTokens(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		msg  string
+		opts []Option
+		// want is the number of permissions issued right away.
+		want int
+	}{
+		{msg: "empty", opts: []Option{WithInitialTokens(0)}, want: 0},
+		{msg: "partial", opts: []Option{WithBurst(5), WithInitialTokens(2)}, want: 2},
+		{msg: "full", opts: []Option{WithBurst(5), WithInitialTokens(5)}, want: 5},
+		{msg: "without slack", opts: []Option{WithoutSlack, WithInitialTokens(1)}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			runTest(t, func(r testRunner) {
+				rl := r.createLimiter(10, tt.opts...).(TryLimiter)
+
+				for i := 0; i < tt.want; i++ {
+					ok, _ := rl.TryTake()
+					assert.True(t, ok, "take %d should be allowed", i)
+				}
+				ok, retryAfter := rl.TryTake()
+				assert.False(t, ok)
+				assert.Equal(t, 100*time.Millisecond, retryAfter)
+			})
+		})
+	}
+}
+
+func TestInitialTokensAccumulate(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithBurst(5), WithInitialTokens(0)).(TryLimiter)
+		clk := r.getClock()
+
+		// Tokens accumulate from the creation of the limiter,
+		// up to the burst.
+		clk.Add(250 * time.Millisecond)
+		for i := 0; i < 2; i++ {
+			ok, _ := rl.TryTake()
+			assert.True(t, ok, "take %d should be allowed", i)
+		}
+		ok, retryAfter := rl.TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 50*time.Millisecond, retryAfter)
+
+		clk.Add(10 * time.Second)
+		for i := 0; i < 5; i++ {
+			ok, _ := rl.TryTake()
+			assert.True(t, ok, "take %d should be allowed", i)
+		}
+		ok, _ = rl.TryTake()
+		assert.False(t, ok)
+	})
+}
+
+// weighted is a Limiter that takes n permissions on every Take.
+type weighted struct {
+	WeightedLimiter
+
+	n int
+}
+
+func (w weighted) Take() time.Time {
+	return w.TakeN(w.n)
+}
+
+func TestTakeN(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(100, WithoutSlack).(WeightedLimiter)
+
+		// Each iteration costs 50ms, the first one included.
+		r.startTaking(weighted{rl, 5})
+
+		r.assertCountAt(1*time.Second, 20)
+		r.assertCountAt(2*time.Second, 40)
+	})
+}
+
+func TestTryTakeN(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithSlack(2)).(WeightedLimiter)
+		clk := r.getClock()
+
+		ok, _ := rl.TryTakeN(1)
+		assert.True(t, ok)
+
+		ok, retryAfter := rl.TryTakeN(0)
+		assert.True(t, ok, "nothing to take")
+		assert.Zero(t, retryAfter)
+
+		// Taking more than the burst never succeeds: retryAfter is how
+		// long TakeN would wait for the remainder.
+		clk.Add(time.Second)
+		ok, retryAfter = rl.TryTakeN(5)
+		assert.False(t, ok)
+		assert.Equal(t, 200*time.Millisecond, retryAfter)
+		clk.Add(retryAfter)
+		ok, _ = rl.TryTakeN(5)
+		assert.False(t, ok, "should fail again after retryAfter")
+
+		// All or nothing, so the slack is still there.
+		ok, _ = rl.TryTakeN(3)
+		assert.True(t, ok)
+
+		ok, retryAfter = rl.TryTakeN(2)
+		assert.False(t, ok)
+		assert.Equal(t, 200*time.Millisecond, retryAfter)
+	})
+}
+
+func TestTakeNOverflow(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(1, Per(time.Hour)).(WeightedLimiter)
+		clk := r.getClock()
+
+		// Waits longer than can be tracked are never granted.
+		for _, n := range []int{3_000_000, math.MaxInt} {
+			ok, retryAfter := rl.TryTakeN(n)
+			assert.False(t, ok, "take %d", n)
+			assert.True(t, retryAfter > 50*365*24*time.Hour, "retry after %v", retryAfter)
+		}
+		ctx, cancel := clk.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		_, err := rl.TakeNContext(ctx, math.MaxInt)
+		assert.Equal(t, ErrWaitExceedsDeadline, err)
+		assert.False(t, rl.(ReservingLimiter).Reserve(math.MaxInt).OK())
+
+		ok, _ := rl.TryTakeN(1)
+		assert.True(t, ok)
+		ok, _ = rl.TryTakeN(1)
+		assert.False(t, ok)
+		assert.Zero(t, rl.(StatsLimiter).Stats().Available)
+	})
+}
+
+func TestTakeNContext(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(WeightedLimiter)
+		clk := r.getClock()
+		start, err := rl.TakeNContext(context.Background(), 1)
+		assert.NoError(t, err)
+
+		ctx, cancel := clk.WithTimeout(context.Background(), 250*time.Millisecond)
+		defer cancel()
+		_, err = rl.TakeNContext(ctx, 3)
+		assert.Equal(t, ErrWaitExceedsDeadline, err)
+
+		results := make(chan time.Time)
+		var startWg sync.WaitGroup
+		startWg.Add(1)
+		go func() {
+			startWg.Done()
+			ts, err := rl.TakeNContext(ctx, 2)
+			assert.NoError(t, err)
+			results <- ts
+		}()
+
+		startWg.Wait()
+		clk.Add(200 * time.Millisecond)
+		assert.Equal(t, start.Add(200*time.Millisecond), <-results)
+	})
+}
+
+func TestReserve(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(ReservingLimiter)
+		clk := r.getClock()
+
+		first := rl.Reserve(1)
+		assert.True(t, first.OK())
+		assert.Zero(t, first.Delay(), "first take is allowed")
+
+		res := rl.Reserve(3)
+		assert.True(t, res.OK())
+		assert.Equal(t, 300*time.Millisecond, res.Delay())
+		assert.Equal(t, first.IssuedAt().Add(300*time.Millisecond), res.IssuedAt())
+
+		clk.Add(100 * time.Millisecond)
+		assert.Equal(t, 200*time.Millisecond, res.Delay())
+
+		// The cancelled permissions go to the next caller.
+		res.Cancel()
+		res.Cancel()
+		next := rl.Reserve(1)
+		assert.Zero(t, next.Delay())
+		assert.Equal(t, 100*time.Millisecond, rl.Reserve(1).Delay())
+	})
+}
+
+func TestReserveCancelIssued(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(ReservingLimiter)
+		clk := r.getClock()
+
+		rl.Reserve(1)
+		res := rl.Reserve(1)
+		assert.Equal(t, 100*time.Millisecond, res.Delay())
+
+		// Issued permissions can't be given back.
+		clk.Add(100 * time.Millisecond)
+		assert.Zero(t, res.Delay())
+		res.Cancel()
+		assert.Equal(t, 100*time.Millisecond, rl.Reserve(1).Delay())
+	})
+}
+
+func TestReserveZero(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(ReservingLimiter)
+
+		rl.Reserve(1)
+		res := rl.Reserve(0)
+		assert.True(t, res.OK())
+		assert.Zero(t, res.Delay())
+		res.Cancel()
+		assert.Equal(t, 100*time.Millisecond, rl.Reserve(1).Delay(), "nothing should be reserved or given back")
+	})
+}
+
+func TestSetRate(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack)
+		adj := rl.(AdjustableLimiter)
+		res := rl.(ReservingLimiter)
+
+		assert.Zero(t, res.Reserve(1).Delay())
+		assert.Equal(t, 100*time.Millisecond, res.Reserve(1).Delay())
+
+		// Permissions already handed out keep their times.
+		assert.NoError(t, adj.SetRate(100))
+		assert.Equal(t, 110*time.Millisecond, res.Reserve(1).Delay())
+
+		assert.NoError(t, adj.SetLimit(2, time.Second))
+		assert.Equal(t, 610*time.Millisecond, res.Reserve(1).Delay())
+	})
+}
+
+func TestSetRateWhileTaking(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(100, WithoutSlack)
+
+		r.startTaking(rl)
+		r.startTaking(rl)
+
+		// With two goroutines, the permissions issued at 1010ms and 1020ms
+		// were handed out at the old rate, the rest are 5ms apart.
+		r.afterFunc(1005*time.Millisecond, func() {
+			assert.NoError(t, rl.(AdjustableLimiter).SetRate(200))
+		})
+
+		r.assertCountAt(500*time.Millisecond, 50)
+		r.assertCountAt(2502*time.Millisecond, 399)
+	})
+}
+
+func TestSetSlack(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack)
+		clk := r.getClock()
+		try := rl.(TryLimiter)
+
+		ok, _ := try.TryTake()
+		assert.True(t, ok)
+
+		clk.Add(time.Second)
+		assert.NoError(t, rl.(AdjustableLimiter).SetSlack(2))
+		for i := 0; i < 3; i++ {
+			ok, _ := try.TryTake()
+			assert.True(t, ok, "take %d should be allowed", i)
+		}
+		ok, retryAfter := try.TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 100*time.Millisecond, retryAfter)
+	})
+}
+
+func TestFractionalRate(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(0.5, WithoutSlack, Per(time.Minute))
+
+		r.startTaking(rl)
+		r.startTaking(rl)
+
+		r.assertCountAt(1*time.Minute, 1)
+		r.assertCountAt(5*time.Minute, 3)
+	})
+}
+
+func TestFractionalRateSpacing(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(1.5, WithoutSlack).(ReservingLimiter)
+
+		rl.Reserve(1)
+		assert.Equal(t, 666666666*time.Nanosecond, rl.Reserve(1).Delay())
+		assert.Equal(t, 1333333332*time.Nanosecond, rl.Reserve(1).Delay())
+	})
+}
+
+func TestSetInvalid(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack).(AdjustableLimiter)
+
+		assert.EqualError(t, rl.SetRate(0), "ratelimit: rate must be positive, got 0")
+		assert.EqualError(t, rl.SetRate(Rate(math.NaN())), "ratelimit: rate must be positive, got NaN")
+		assert.EqualError(t, rl.SetLimit(1, 0), "ratelimit: per must be positive, got 0s")
+		assert.EqualError(t, rl.SetSlack(-1), "ratelimit: slack must not be negative, got -1")
+		assert.EqualError(t, rl.SetRate(1e-12), "ratelimit: rate is too low, got 1e-12 per 1s")
+		assert.EqualError(t, rl.SetLimit(1e-6, 24*time.Hour), "ratelimit: rate is too low, got 1e-06 per 24h0m0s")
+
+		assert.NoError(t, rl.SetRate(2.5))
+	})
+}
+
+func TestLowestRate(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(1e-9).(TryLimiter)
+		r.getClock().Add(time.Hour)
+
+		ok, _ := rl.TryTake()
+		assert.True(t, ok)
+		ok, retryAfter := rl.TryTake()
+		assert.False(t, ok)
+		assert.True(t, retryAfter > 0, "retry after %v", retryAfter)
+	})
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithSlack(2), WithStats())
+		clk := r.getClock()
+		start := clk.Now()
+
+		stats := rl.(StatsLimiter).Stats()
+		assert.Equal(t, Rate(10), stats.Rate)
+		assert.Equal(t, time.Second, stats.Per)
+		assert.Equal(t, 2, stats.Slack)
+		assert.Equal(t, 1, stats.Available, "first take is allowed")
+		assert.WithinDuration(t, start, stats.NextPermission, 0)
+
+		ok, _ := rl.(TryLimiter).TryTake()
+		assert.True(t, ok)
+		stats = rl.(StatsLimiter).Stats()
+		assert.Zero(t, stats.Available)
+		assert.WithinDuration(t, start.Add(100*time.Millisecond), stats.NextPermission, 0)
+		assert.Equal(t, int64(1), stats.Takes)
+
+		clk.Add(time.Second)
+		stats = rl.(StatsLimiter).Stats()
+		assert.Equal(t, 3, stats.Available, "slack should be available")
+		assert.WithinDuration(t, clk.Now(), stats.NextPermission, 0)
+
+		res := rl.(ReservingLimiter).Reserve(4)
+		stats = rl.(StatsLimiter).Stats()
+		assert.Equal(t, int64(5), stats.Takes)
+		assert.Equal(t, int64(1), stats.Throttled)
+		assert.Equal(t, 100*time.Millisecond, stats.Waited)
+
+		res.Cancel()
+		assert.Equal(t, int64(1), rl.(StatsLimiter).Stats().Takes, "cancelled takes don't count")
+	})
+}
+
+func TestStatsTake(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack, WithStats())
+		clk := r.getClock()
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer wg.Done()
+				rl.Take()
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		clk.Add(100 * time.Millisecond)
+		wg.Wait()
+
+		stats := rl.(StatsLimiter).Stats()
+		assert.Equal(t, int64(2), stats.Takes)
+		assert.Equal(t, int64(1), stats.Throttled)
+		assert.Equal(t, 100*time.Millisecond, stats.Waited)
+	})
+}
+
+func TestStatsDisabled(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithoutSlack)
+
+		rl.(WeightedLimiter).TryTakeN(1)
+		stats := rl.(StatsLimiter).Stats()
+		assert.Equal(t, Rate(10), stats.Rate)
+		assert.Zero(t, stats.Takes, "counters need WithStats")
+	})
+}
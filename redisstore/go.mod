@@ -0,0 +1,26 @@
+module go.uber.org/ratelimit/redisstore
+
+go 1.20
+
+// Builds against the root module of this repository. Consumers ignore the
+// replace, and get the version required below, which must be released
+// first.
+replace go.uber.org/ratelimit => ../
+
+require (
+	github.com/alicebob/miniredis/v2 v2.32.1
+	github.com/benbjohnson/clock v1.3.0
+	github.com/redis/go-redis/v9 v9.5.1
+	github.com/stretchr/testify v1.6.1
+	go.uber.org/ratelimit v0.4.0
+)
+
+require (
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
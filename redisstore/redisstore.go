@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package redisstore provides a ratelimit.Store backed by Redis, to share
+// limits across processes:
+//
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	rl, err := ratelimit.NewDistributed(redisstore.New(client), "ratelimit:api", 100)
+//
+// It's a separate module so that the ratelimit package doesn't depend
+// on Redis.
+package redisstore // import "go.uber.org/ratelimit/redisstore"
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/ratelimit"
+)
+
+// compareAndSwap sets KEYS[1] to ARGV[2] with a TTL of ARGV[3]
+// milliseconds if it's ARGV[1], where "0" stands for a missing key.
+var compareAndSwap = redis.NewScript(`
+local current = redis.call("GET", KEYS[1]) or "0"
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// Client is the part of a Redis client used by the Store. It's implemented
+// by *redis.Client, *redis.ClusterClient and *redis.Ring.
+type Client interface {
+	redis.Scripter
+	Get(ctx context.Context, key string) *redis.StringCmd
+}
+
+// Store is a ratelimit.Store that holds the state of limiters in Redis.
+type Store struct {
+	client Client
+}
+
+var _ ratelimit.Store = (*Store)(nil)
+
+// New returns a Store that holds the state of limiters in Redis, through
+// the given client.
+func New(client Client) *Store {
+	return &Store{client: client}
+}
+
+// Get returns the state of the key.
+func (s *Store) Get(ctx context.Context, key string) (int64, error) {
+	v, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return v, err
+}
+
+// CompareAndSwap sets the state of the key to new if it's old, and lets
+// Redis expire it after ttl.
+func (s *Store) CompareAndSwap(ctx context.Context, key string, old, new int64, ttl time.Duration) (bool, error) {
+	// Rounding up keeps the state for at least ttl, and Redis rejects
+	// expiration times below one millisecond.
+	ttlMillis := int64((ttl + time.Millisecond - 1) / time.Millisecond)
+	if ttlMillis < 1 {
+		ttlMillis = 1
+	}
+	swapped, err := compareAndSwap.Run(ctx, s.client, []string{key},
+		strconv.FormatInt(old, 10), strconv.FormatInt(new, 10), ttlMillis,
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
@@ -0,0 +1,105 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/benbjohnson/clock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/ratelimit"
+)
+
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client), mr
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s, mr := newStore(t)
+
+	v, err := s.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Zero(t, v, "missing keys should have no state")
+
+	swapped, err := s.CompareAndSwap(ctx, "key", 1, 2, time.Second)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = s.CompareAndSwap(ctx, "key", 0, 42, time.Second)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	v, err = s.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), v)
+
+	swapped, err = s.CompareAndSwap(ctx, "key", 0, 43, time.Second)
+	require.NoError(t, err)
+	assert.False(t, swapped, "the state is no longer missing")
+
+	mr.FastForward(time.Second)
+	v, err = s.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Zero(t, v, "the state should expire")
+}
+
+func TestCompareAndSwapTTL(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s, mr := newStore(t)
+
+	_, err := s.CompareAndSwap(ctx, "short", 0, 1, time.Microsecond)
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond, mr.TTL("short"))
+
+	_, err = s.CompareAndSwap(ctx, "rounded", 0, 1, 1500*time.Microsecond)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Millisecond, mr.TTL("rounded"))
+}
+
+func TestStoreDown(t *testing.T) {
+	t.Parallel()
+	s, mr := newStore(t)
+	mr.Close()
+
+	_, err := s.Get(context.Background(), "key")
+	assert.Error(t, err)
+	_, err = s.CompareAndSwap(context.Background(), "key", 0, 1, time.Second)
+	assert.Error(t, err)
+}
+
+func TestDistributedLimiter(t *testing.T) {
+	t.Parallel()
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	mr := miniredis.RunT(t)
+
+	// Each limiter has its own client, like separate processes would.
+	var limiters []*ratelimit.DistributedLimiter
+	for i := 0; i < 2; i++ {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		l, err := ratelimit.NewDistributed(New(client), "ratelimit:test", 10,
+			ratelimit.WithoutSlack, ratelimit.WithClock(clk))
+		require.NoError(t, err)
+		limiters = append(limiters, l)
+	}
+
+	ok, _ := limiters[0].TryTake()
+	assert.True(t, ok)
+	ok, retryAfter := limiters[1].TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	clk.Add(100 * time.Millisecond)
+	ok, _ = limiters[1].TryTake()
+	assert.True(t, ok)
+	assert.True(t, mr.Exists("ratelimit:test"))
+}
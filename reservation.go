@@ -0,0 +1,107 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Reservation holds permissions taken from a limiter ahead of time.
+// It's safe for concurrent use.
+type Reservation struct {
+	ok       bool
+	n        int
+	issuedAt time.Time
+
+	// clock and limiter are nil if there's nothing to give back.
+	clock     Clock
+	limiter   reserver
+	cancelled int32
+}
+
+// newReservation reserves n permissions from r.
+func newReservation(clock Clock, r reserver, n int) *Reservation {
+	now := clock.Now()
+	if n < 1 {
+		return &Reservation{ok: true, issuedAt: now}
+	}
+
+	issuedAt, ok := r.reserve(now, n, math.MaxInt64)
+	if !ok {
+		// The permissions are too far ahead to be tracked.
+		return &Reservation{issuedAt: issuedAt}
+	}
+	r.record(n, issuedAt.Sub(now))
+	return &Reservation{
+		ok:       true,
+		n:        n,
+		issuedAt: issuedAt,
+		clock:    clock,
+		limiter:  r,
+	}
+}
+
+// OK reports whether the limiter can provide the permissions at all, which
+// a limiter with a zero rate can't, nor any limiter if they're issued
+// further ahead than it can track, decades from now. If it can't, Delay
+// returns the maximum time.Duration and Cancel does nothing.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// IssuedAt returns the time the permissions are issued at.
+func (r *Reservation) IssuedAt() time.Time {
+	return r.issuedAt
+}
+
+// Delay returns how long the caller should wait before acting on the
+// permissions. It's zero once they are issued.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return math.MaxInt64
+	}
+	if r.clock == nil {
+		return 0
+	}
+	if d := r.issuedAt.Sub(r.clock.Now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel gives the permissions back to the limiter, so that other callers
+// can use them. Permissions that were already issued can't be given back,
+// in which case Cancel does nothing. Calling Cancel more than once has no
+// further effect.
+func (r *Reservation) Cancel() {
+	if !r.ok || r.limiter == nil {
+		return
+	}
+	if !r.clock.Now().Before(r.issuedAt) {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&r.cancelled, 0, 1) {
+		r.limiter.refund(r.n)
+		r.limiter.record(-r.n, 0)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Schedule returns the rate of a limiter at a given time. It's called for
+// every permission, so it must be fast and safe for concurrent use. A rate
+// of 0 denies all permissions, such as during maintenance, and so do
+// negative rates, NaN and rates too low to space permissions at.
+type Schedule func(time.Time) Rate
+
+// ScheduledLimiter is a Limiter whose rate follows a Schedule, such as a
+// higher rate at night than during business hours.
+//
+// The rate changes without disrupting the pacing of permissions: the ones
+// issued before a change are spaced at the old rate, and the ones after
+// it at the new rate, optionally ramping up or down to it
+// WithTransition. It implements the same interfaces as the limiters
+// returned by New, except for AdjustableLimiter.
+//
+// While the schedule denies permissions, TryTake fails with the Per
+// window, up to a second, as the time to retry after, Reserve returns a
+// Reservation that isn't OK, and Take waits, checking the schedule again
+// as often.
+// Once it allows them again, the limiter moves from the last rate it
+// allowed to the new one.
+type ScheduledLimiter struct {
+	limiter  *atomicInt64Limiter
+	schedule *schedule
+}
+
+// NewScheduled returns a ScheduledLimiter that follows the schedule s, at
+// the time of the clock configured WithClock. The schedule must allow
+// permissions when the limiter is created.
+func NewScheduled(s Schedule, opts ...Option) (*ScheduledLimiter, error) {
+	config := buildConfig(opts)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if config.transition < 0 {
+		return nil, fmt.Errorf("ratelimit: transition must not be negative, got %v", config.transition)
+	}
+	now := config.clock.Now()
+	rate := s(now)
+	if err := checkRate(rate); err != nil {
+		return nil, err
+	}
+	if err := checkRateLow(rate, config.per); err != nil {
+		return nil, err
+	}
+
+	l := newAtomicInt64Based(rate, opts...)
+	sched := &schedule{
+		rate:     s,
+		per:      config.per,
+		duration: config.transition,
+	}
+	sched.current.Store(&transition{from: rate, to: rate, at: now, limits: *l.load()})
+	l.schedule = sched
+	return &ScheduledLimiter{limiter: l, schedule: sched}, nil
+}
+
+// Take blocks until the schedule allows a permission and it's issued.
+func (s *ScheduledLimiter) Take() time.Time {
+	return s.TakeN(1)
+}
+
+// TakeContext is like Take, but can be cancelled through ctx.
+func (s *ScheduledLimiter) TakeContext(ctx context.Context) (time.Time, error) {
+	return s.TakeNContext(ctx, 1)
+}
+
+// TryTake takes a permission only if it doesn't have to wait for it.
+func (s *ScheduledLimiter) TryTake() (bool, time.Duration) {
+	return s.TryTakeN(1)
+}
+
+// TakeN is like Take, for n permissions.
+func (s *ScheduledLimiter) TakeN(n int) time.Time {
+	if n >= 1 {
+		// The background context is never done.
+		_ = s.wait(context.Background())
+	}
+	return s.limiter.TakeN(n)
+}
+
+// TryTakeN is like TryTake, for n permissions.
+func (s *ScheduledLimiter) TryTakeN(n int) (bool, time.Duration) {
+	if n >= 1 && s.schedule.denies(s.limiter.clock.Now()) {
+		return false, s.schedule.poll()
+	}
+	return s.limiter.TryTakeN(n)
+}
+
+// TakeNContext is like TakeContext, for n permissions.
+func (s *ScheduledLimiter) TakeNContext(ctx context.Context, n int) (time.Time, error) {
+	if n >= 1 {
+		if err := s.wait(ctx); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return s.limiter.TakeNContext(ctx, n)
+}
+
+// Reserve takes n permissions without waiting for them.
+func (s *ScheduledLimiter) Reserve(n int) *Reservation {
+	if now := s.limiter.clock.Now(); n >= 1 && s.schedule.denies(now) {
+		return &Reservation{issuedAt: now}
+	}
+	return s.limiter.Reserve(n)
+}
+
+// Stats returns a snapshot of the limiter. While the schedule denies
+// permissions, it reports a rate of 0 and none available.
+func (s *ScheduledLimiter) Stats() Stats {
+	if s.schedule.denies(s.limiter.clock.Now()) {
+		l := s.limiter.load()
+		return s.limiter.counters.fill(Stats{Per: l.per, Slack: l.slack})
+	}
+	return s.limiter.Stats()
+}
+
+// wait blocks while the schedule denies permissions.
+func (s *ScheduledLimiter) wait(ctx context.Context) error {
+	for s.schedule.denies(s.limiter.clock.Now()) {
+		if err := sleepContext(ctx, s.limiter.clock, s.schedule.poll()); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// schedule adjusts the limits of a limiter to a Schedule. A nil schedule
+// doesn't adjust anything.
+type schedule struct {
+	rate Schedule
+	per  time.Duration
+	// duration is the time it takes to move from a rate to the next.
+	duration time.Duration
+	current  atomic.Pointer[transition]
+}
+
+// transition is a move from a rate to the next, which started at a time.
+type transition struct {
+	from, to Rate
+	at       time.Time
+	// limits are the limits at the rate moved to.
+	limits limits
+}
+
+// rate returns the rate of the transition at now, which moves linearly
+// from the old rate to the new one over d.
+func (t *transition) rate(now time.Time, d time.Duration) Rate {
+	elapsed := now.Sub(t.at)
+	if elapsed >= d {
+		return t.to
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return t.from + (t.to-t.from)*Rate(float64(elapsed)/float64(d))
+}
+
+// poll returns how often to check a schedule that denies permissions:
+// every Per window, up to a second, so that it's noticed soon enough when
+// it allows them again.
+func (s *schedule) poll() time.Duration {
+	if s.per < time.Second {
+		return s.per
+	}
+	return time.Second
+}
+
+// denies reports whether the schedule denies permissions at now.
+func (s *schedule) denies(now time.Time) bool {
+	return !s.allows(s.rate(now))
+}
+
+// allows reports whether permissions can be spaced at rate.
+func (s *schedule) allows(rate Rate) bool {
+	return rate > 0 && checkRateLow(rate, s.per) == nil
+}
+
+// limits returns the limits that apply at now, given the limits the
+// limiter was created with. If the schedule denies permissions at now,
+// they are the limits at the last rate it allowed.
+func (s *schedule) limits(l *limits, now time.Time) *limits {
+	if s == nil {
+		return l
+	}
+
+	rate := s.rate(now)
+	current := s.current.Load()
+	if rate != current.to && s.allows(rate) {
+		next := &transition{
+			from:   current.rate(now, s.duration),
+			to:     rate,
+			at:     now,
+			limits: newLimits(rate, l.per, l.slack),
+		}
+		if s.current.CompareAndSwap(current, next) {
+			current = next
+		} else {
+			// Another caller saw the change at the same time.
+			current = s.current.Load()
+		}
+	}
+
+	if now.Sub(current.at) >= s.duration {
+		return &current.limits
+	}
+	moving := newLimits(current.rate(now, s.duration), l.per, l.slack)
+	return &moving
+}
+
+type transitionOption time.Duration
+
+func (o transitionOption) apply(c *config) {
+	c.transition = time.Duration(o)
+}
+
+// WithTransition configures a ScheduledLimiter to move linearly from a
+// rate to the next over the given duration, instead of switching to it
+// right away. It has no effect on other limiters.
+func WithTransition(d time.Duration) Option {
+	return transitionOption(d)
+}
+
+// Window is a span of the day, on some days of the week, during which a
+// schedule applies a rate.
+type Window struct {
+	// Days are the days the window starts on, or every day if empty.
+	Days []time.Weekday
+	// Start and End are the times of day, on the clock, that the window
+	// starts and ends at, such as 9*time.Hour for 9am. A window that ends
+	// before it starts ends the next day, and one that ends when it starts
+	// lasts a whole day.
+	Start, End time.Duration
+	// Rate is the rate during the window, or 0 to deny all permissions.
+	Rate Rate
+}
+
+// Windows returns a Schedule of the given rate, except during the windows,
+// in the time zone of loc, such as time.Local. Where windows overlap, the
+// first one applies.
+func Windows(loc *time.Location, rate Rate, windows ...Window) (Schedule, error) {
+	if loc == nil {
+		return nil, errors.New("ratelimit: location of windows must not be nil")
+	}
+	if err := checkRate(rate); err != nil {
+		return nil, err
+	}
+	for _, w := range windows {
+		if w.Start < 0 || w.Start >= 24*time.Hour || w.End < 0 || w.End > 24*time.Hour {
+			return nil, fmt.Errorf("ratelimit: window must start and end within a day, got %v and %v", w.Start, w.End)
+		}
+		if w.Rate != 0 {
+			if err := checkRate(w.Rate); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	windows = append([]Window(nil), windows...)
+	return func(t time.Time) Rate {
+		t = t.In(loc)
+		hour, minute, sec := t.Clock()
+		timeOfDay := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute +
+			time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+		for _, w := range windows {
+			if w.contains(t.Weekday(), timeOfDay) {
+				return w.Rate
+			}
+		}
+		return rate
+	}, nil
+}
+
+// contains reports whether the window contains the time of day of the
+// given weekday.
+func (w Window) contains(day time.Weekday, timeOfDay time.Duration) bool {
+	if w.Start < w.End {
+		return w.startsOn(day) && timeOfDay >= w.Start && timeOfDay < w.End
+	}
+	// The window ends the day after it starts.
+	yesterday := (day + 6) % 7
+	return (w.startsOn(day) && timeOfDay >= w.Start) ||
+		(w.startsOn(yesterday) && timeOfDay < w.End)
+}
+
+func (w Window) startsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
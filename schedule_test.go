@@ -0,0 +1,328 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchAt returns a schedule that switches from a rate to another at a
+// given time.
+func switchAt(at time.Time, before, after Rate) Schedule {
+	return func(t time.Time) Rate {
+		if t.Before(at) {
+			return before
+		}
+		return after
+	}
+}
+
+func TestScheduledInvalid(t *testing.T) {
+	t.Parallel()
+	constant := func(time.Time) Rate { return 10 }
+
+	_, err := NewScheduled(func(time.Time) Rate { return 0 })
+	assert.EqualError(t, err, "ratelimit: rate must be positive, got 0")
+	_, err = NewScheduled(constant, WithTransition(-time.Second))
+	assert.EqualError(t, err, "ratelimit: transition must not be negative, got -1s")
+	_, err = NewScheduled(constant, WithSlack(-1))
+	assert.EqualError(t, err, "ratelimit: slack must not be negative, got -1")
+}
+
+func TestScheduledDeny(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	start := clk.Now()
+	// Maintenance from 1s to 3s.
+	rl, err := NewScheduled(func(now time.Time) Rate {
+		if elapsed := now.Sub(start); elapsed >= time.Second && elapsed < 3*time.Second {
+			return 0
+		}
+		return 10
+	}, WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	clk.Add(time.Second)
+	ok, retryAfter := rl.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+	assert.False(t, rl.Reserve(1).OK())
+	assert.Equal(t, Stats{Per: time.Second}, rl.Stats())
+	ok, _ = rl.TryTakeN(0)
+	assert.True(t, ok, "no permissions are always allowed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = rl.TakeContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// Take waits for the end of the maintenance.
+	results := make(chan time.Time)
+	var startWg sync.WaitGroup
+	startWg.Add(1)
+	go func() {
+		startWg.Done()
+		results <- rl.Take()
+	}()
+	startWg.Wait()
+	clk.Add(time.Second)
+	clk.Add(time.Second)
+	assert.Equal(t, start.Add(3*time.Second), <-results)
+
+	ok, retryAfter = rl.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+	assert.Equal(t, Rate(10), rl.Stats().Rate)
+}
+
+func TestScheduledDenyPoll(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	start := clk.Now()
+	rl, err := NewScheduled(func(now time.Time) Rate {
+		if elapsed := now.Sub(start); elapsed >= time.Second && elapsed < 2500*time.Millisecond {
+			return 0
+		}
+		return 10
+	}, Per(time.Hour), WithClock(clk))
+	require.NoError(t, err)
+
+	// The schedule is checked every second, not every hour.
+	clk.Add(time.Second)
+	ok, retryAfter := rl.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	results := make(chan time.Time)
+	var startWg sync.WaitGroup
+	startWg.Add(1)
+	go func() {
+		startWg.Done()
+		results <- rl.Take()
+	}()
+	startWg.Wait()
+	clk.Add(time.Second)
+	clk.Add(time.Second)
+	assert.Equal(t, start.Add(3*time.Second), <-results)
+}
+
+func TestScheduledInvalidRate(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	rates := []Rate{-1, Rate(math.NaN()), 1e-12}
+	var i atomic.Int32
+	rl, err := NewScheduled(func(time.Time) Rate {
+		if n := i.Load(); n > 0 {
+			return rates[(n-1)%int32(len(rates))]
+		}
+		return 10
+	}, WithClock(clk))
+	require.NoError(t, err)
+
+	for range rates {
+		i.Add(1)
+		ok, retryAfter := rl.TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, time.Second, retryAfter)
+	}
+}
+
+func TestScheduled(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	rl, err := NewScheduled(switchAt(clk.Now().Add(time.Minute), 1, 10), WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	ok, _ := rl.TryTake()
+	assert.True(t, ok)
+	ok, retryAfter := rl.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+	assert.Equal(t, Rate(1), rl.Stats().Rate)
+
+	clk.Add(time.Minute)
+	ok, _ = rl.TryTake()
+	assert.True(t, ok)
+	ok, retryAfter = rl.TryTake()
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+	assert.Equal(t, Rate(10), rl.Stats().Rate)
+}
+
+func TestScheduledSwitch(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	start := clk.Now()
+	rl, err := NewScheduled(switchAt(start.Add(1500*time.Millisecond), 1, 10), WithClock(clk))
+	require.NoError(t, err)
+
+	// Permissions reserved before the switch are spaced at the old rate,
+	// and the ones after it at the new rate, without a burst.
+	var issued []time.Duration
+	for i := 0; i < 3; i++ {
+		issued = append(issued, rl.Reserve(1).IssuedAt().Sub(start))
+	}
+	clk.Add(1500 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		issued = append(issued, rl.Reserve(1).IssuedAt().Sub(start))
+	}
+	assert.Equal(t, []time.Duration{
+		0, time.Second, 2 * time.Second,
+		2100 * time.Millisecond, 2200 * time.Millisecond, 2300 * time.Millisecond,
+	}, issued)
+}
+
+func TestScheduledTransition(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	at := clk.Now().Add(time.Minute)
+	rl, err := NewScheduled(switchAt(at, 10, 20), WithTransition(10*time.Second), WithClock(clk))
+	require.NoError(t, err)
+
+	clk.Add(time.Minute)
+	assert.Equal(t, Rate(10), rl.Stats().Rate, "the transition starts at the old rate")
+	clk.Add(5 * time.Second)
+	assert.Equal(t, Rate(15), rl.Stats().Rate)
+	clk.Add(5 * time.Second)
+	assert.Equal(t, Rate(20), rl.Stats().Rate)
+}
+
+func TestScheduledTransitionReverses(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	start := clk.Now()
+	schedule := func(t time.Time) Rate {
+		if t.Sub(start) >= time.Minute && t.Sub(start) < time.Minute+5*time.Second {
+			return 20
+		}
+		return 10
+	}
+	rl, err := NewScheduled(schedule, WithTransition(10*time.Second), WithClock(clk))
+	require.NoError(t, err)
+
+	clk.Add(time.Minute)
+	assert.Equal(t, Rate(10), rl.Stats().Rate)
+	clk.Add(5 * time.Second)
+	// The rate goes back from where the interrupted transition got to.
+	assert.Equal(t, Rate(15), rl.Stats().Rate)
+	clk.Add(5 * time.Second)
+	assert.Equal(t, Rate(12.5), rl.Stats().Rate)
+	clk.Add(5 * time.Second)
+	assert.Equal(t, Rate(10), rl.Stats().Rate)
+}
+
+func TestWindowsInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		msg     string
+		loc     *time.Location
+		rate    Rate
+		windows []Window
+		wantErr string
+	}{
+		{
+			msg:     "nil location",
+			rate:    10,
+			wantErr: "ratelimit: location of windows must not be nil",
+		},
+		{
+			msg:     "rate",
+			loc:     time.UTC,
+			rate:    -1,
+			wantErr: "ratelimit: rate must be positive, got -1",
+		},
+		{
+			msg:     "window rate",
+			loc:     time.UTC,
+			rate:    10,
+			windows: []Window{{Start: time.Hour, End: 2 * time.Hour, Rate: -1}},
+			wantErr: "ratelimit: rate must be positive, got -1",
+		},
+		{
+			msg:     "start",
+			loc:     time.UTC,
+			rate:    10,
+			windows: []Window{{Start: 24 * time.Hour, End: time.Hour, Rate: 1}},
+			wantErr: "ratelimit: window must start and end within a day, got 24h0m0s and 1h0m0s",
+		},
+		{
+			msg:     "end",
+			loc:     time.UTC,
+			rate:    10,
+			windows: []Window{{Start: time.Hour, End: -time.Hour, Rate: 1}},
+			wantErr: "ratelimit: window must start and end within a day, got 1h0m0s and -1h0m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := Windows(tt.loc, tt.rate, tt.windows...)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWindows(t *testing.T) {
+	t.Parallel()
+	loc := time.FixedZone("EST", -5*60*60)
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	schedule, err := Windows(loc, 500,
+		// Business hours.
+		Window{Days: weekdays, Start: 9 * time.Hour, End: 17 * time.Hour, Rate: 50},
+		// Maintenance on Saturday nights.
+		Window{Days: []time.Weekday{time.Saturday}, Start: 22 * time.Hour, End: 2 * time.Hour, Rate: 1},
+		// Overlaps with business hours, which come first.
+		Window{Start: 12 * time.Hour, End: 14 * time.Hour, Rate: 100},
+		// A whole day.
+		Window{Days: []time.Weekday{time.Sunday}, Start: 6 * time.Hour, End: 6 * time.Hour, Rate: 200},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		at   string
+		want Rate
+	}{
+		{at: "2026-10-12T08:59:59-05:00", want: 500}, // Monday
+		{at: "2026-10-12T09:00:00-05:00", want: 50},
+		{at: "2026-10-12T16:59:59-05:00", want: 50},
+		{at: "2026-10-12T17:00:00-05:00", want: 500},
+		{at: "2026-10-12T14:00:00Z", want: 50},       // 9am in EST
+		{at: "2026-10-17T13:00:00-05:00", want: 100}, // Saturday
+		{at: "2026-10-17T21:59:59-05:00", want: 500},
+		{at: "2026-10-17T22:00:00-05:00", want: 1},
+		{at: "2026-10-18T01:59:59-05:00", want: 1}, // Sunday
+		{at: "2026-10-18T02:00:00-05:00", want: 500},
+		{at: "2026-10-18T06:00:00-05:00", want: 200},
+		{at: "2026-10-19T05:59:59-05:00", want: 200}, // Monday
+		{at: "2026-10-19T06:00:00-05:00", want: 500},
+	}
+
+	for _, tt := range tests {
+		at, err := time.Parse(time.RFC3339, tt.at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, schedule(at), "rate at %v", tt.at)
+	}
+}
+
+func TestWindowsLimiter(t *testing.T) {
+	t.Parallel()
+	clk := clock.NewMock()
+	clk.Set(time.Date(2026, time.October, 12, 8, 0, 0, 0, time.UTC))
+	schedule, err := Windows(time.UTC, 500, Window{Start: 9 * time.Hour, End: 17 * time.Hour, Rate: 50})
+	require.NoError(t, err)
+	rl, err := NewScheduled(schedule, WithClock(clk))
+	require.NoError(t, err)
+
+	assert.Equal(t, Rate(500), rl.Stats().Rate)
+	clk.Add(time.Hour)
+	assert.Equal(t, Rate(50), rl.Stats().Rate)
+	clk.Add(8 * time.Hour)
+	assert.Equal(t, Rate(500), rl.Stats().Rate)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// spacing draws the time between two permissions of a busy limiter, given
+// its average and the time of the first of them, in unix nanoseconds.
+//
+// The draw is a function of the time of the first permission, so callers
+// that race for the next permission, or give up on it, all see the same
+// gap: drawing again on every attempt would favor the short gaps, which
+// are the ones TryTake and deadlines accept.
+type spacing func(avg time.Duration, after int64) time.Duration
+
+type spacingMode int
+
+const (
+	regularSpacing spacingMode = iota
+	jitterSpacing
+	poissonSpacing
+)
+
+// spacing returns the configured spacing of a limiter created now, or nil
+// if permissions are spaced regularly.
+func (c config) spacing(now time.Time) spacing {
+	if c.spacingMode == regularSpacing {
+		return nil
+	}
+
+	src := c.randSource
+	if src == nil {
+		src = rand.NewSource(time.Now().UnixNano())
+	}
+	// Draws depend on the time since the limiter was created, so that
+	// the same seed spaces permissions the same way whenever it starts.
+	seed, epoch := uint64(src.Int63()), now.UnixNano()
+	switch c.spacingMode {
+	case jitterSpacing:
+		jitter := c.jitter
+		return func(avg time.Duration, after int64) time.Duration {
+			u := uniform(seed, after-epoch)
+			return time.Duration(float64(avg) * (1 + jitter*(2*u-1)))
+		}
+	default:
+		return func(avg time.Duration, after int64) time.Duration {
+			u := uniform(seed, after-epoch)
+			return time.Duration(float64(avg) * -math.Log(1-u))
+		}
+	}
+}
+
+// uniform returns a number in [0, 1) that looks random, but is the same
+// for the same seed and key.
+func uniform(seed uint64, key int64) float64 {
+	// The finalizer of SplitMix64.
+	z := seed ^ uint64(key)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return float64(z>>11) / (1 << 53)
+}
+
+func checkJitter(jitter float64) error {
+	if !(jitter >= 0 && jitter <= 1) {
+		return fmt.Errorf("ratelimit: jitter must be between 0 and 1, got %v", jitter)
+	}
+	return nil
+}
+
+type jitterOption float64
+
+func (o jitterOption) apply(c *config) {
+	c.spacingMode = jitterSpacing
+	c.jitter = float64(o)
+}
+
+// WithJitter configures the limiter to space permissions at random, so
+// that clients sharing the same limits don't synchronize. The time between
+// two permissions is drawn uniformly within the given fraction, between 0
+// and 1, of its average: WithJitter(0.2) spaces the permissions of
+// New(100) by 8 to 12 milliseconds.
+//
+// The average rate is preserved. The permissions of a single TakeN or
+// Reserve are spaced as one. It's supported by the limiters returned by
+// New and NewWithRate.
+func WithJitter(fraction float64) Option {
+	return jitterOption(fraction)
+}
+
+type poissonOption struct{}
+
+func (poissonOption) apply(c *config) {
+	c.spacingMode = poissonSpacing
+}
+
+// WithPoisson configures the limiter to space permissions like the
+// arrivals of a Poisson process, with exponentially distributed times
+// between them, which makes realistic load for tests.
+//
+// The average rate is preserved, though permissions are bursty: a lot of
+// them can be issued much closer than the average. The permissions of a
+// single TakeN or Reserve are spaced as one. It's supported by the
+// limiters returned by New and NewWithRate.
+func WithPoisson() Option {
+	return poissonOption{}
+}
+
+type randSourceOption struct {
+	src rand.Source
+}
+
+func (o randSourceOption) apply(c *config) {
+	c.randSource = o.src
+}
+
+// WithRandSource configures the source of the randomness of WithJitter and
+// WithPoisson, such as rand.NewSource(seed) for reproducible tests. The
+// limiter draws a seed from it when it's created. By default, the seed is
+// the time the limiter is created.
+func WithRandSource(src rand.Source) Option {
+	return randSourceOption{src: src}
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reserveGaps reserves n permissions of a busy limiter one after another,
+// and returns the times between them.
+func reserveGaps(t *testing.T, rate Rate, n int, opts ...Option) []time.Duration {
+	clk := newMockClock()
+	rl, err := NewWithRate(rate, append(opts, WithClock(clk))...)
+	require.NoError(t, err)
+
+	last := rl.(ReservingLimiter).Reserve(1).IssuedAt()
+	gaps := make([]time.Duration, n)
+	for i := range gaps {
+		issuedAt := rl.(ReservingLimiter).Reserve(1).IssuedAt()
+		gaps[i] = issuedAt.Sub(last)
+		last = issuedAt
+	}
+	return gaps
+}
+
+func meanGap(gaps []time.Duration) time.Duration {
+	var sum time.Duration
+	for _, gap := range gaps {
+		sum += gap
+	}
+	return sum / time.Duration(len(gaps))
+}
+
+func TestSpacingInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewWithRate(10, WithJitter(1.5))
+	assert.EqualError(t, err, "ratelimit: jitter must be between 0 and 1, got 1.5")
+	_, err = NewWithRate(10, WithJitter(-0.1))
+	assert.EqualError(t, err, "ratelimit: jitter must be between 0 and 1, got -0.1")
+}
+
+func TestJitter(t *testing.T) {
+	t.Parallel()
+	gaps := reserveGaps(t, 100, 10000, WithJitter(0.2), WithRandSource(rand.NewSource(1)))
+
+	distinct := make(map[time.Duration]struct{})
+	for _, gap := range gaps {
+		assert.True(t, gap >= 8*time.Millisecond && gap <= 12*time.Millisecond, "gap of %v", gap)
+		distinct[gap] = struct{}{}
+	}
+	assert.Greater(t, len(distinct), 1000, "gaps should be random")
+	assert.InDelta(t, 10*time.Millisecond, meanGap(gaps), float64(100*time.Microsecond),
+		"should preserve the average rate")
+}
+
+func TestPoisson(t *testing.T) {
+	t.Parallel()
+	gaps := reserveGaps(t, 100, 10000, WithPoisson(), WithRandSource(rand.NewSource(1)))
+
+	short := 0
+	for _, gap := range gaps {
+		assert.True(t, gap >= 0, "gap of %v", gap)
+		if gap < 10*time.Millisecond {
+			short++
+		}
+	}
+	// 1 - 1/e of the exponentially distributed gaps are below average.
+	assert.InDelta(t, 0.632, float64(short)/float64(len(gaps)), 0.02)
+	assert.InDelta(t, 10*time.Millisecond, meanGap(gaps), float64(300*time.Microsecond),
+		"should preserve the average rate")
+}
+
+func TestSpacingSeed(t *testing.T) {
+	t.Parallel()
+	for _, opt := range []Option{WithJitter(0.5), WithPoisson()} {
+		first := reserveGaps(t, 100, 100, opt, WithRandSource(rand.NewSource(42)))
+		second := reserveGaps(t, 100, 100, opt, WithRandSource(rand.NewSource(42)))
+		assert.Equal(t, first, second, "the same seed should space permissions the same way")
+	}
+}
+
+func TestSpacingIdle(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	rl, err := NewWithRate(10, WithPoisson(), WithoutSlack, WithClock(clk))
+	require.NoError(t, err)
+
+	// Permissions of an idle limiter are issued right away.
+	for i := 0; i < 10; i++ {
+		ok, _ := rl.(TryLimiter).TryTake()
+		assert.True(t, ok)
+		clk.Add(time.Minute)
+	}
+}
+
+func TestSpacingPolling(t *testing.T) {
+	t.Parallel()
+	for _, opt := range []Option{WithJitter(1), WithPoisson()} {
+		clk := newMockClock()
+		rl := newAtomicInt64Based(100, opt, WithoutSlack, WithClock(clk), WithRandSource(rand.NewSource(1)))
+
+		// Poll like TryTake every 2ms for 100s, without waiting for the
+		// mock clock.
+		taken := 0
+		now := clk.Now()
+		for i := 0; i < 50000; i++ {
+			if _, ok := rl.reserve(now, 1, 0); ok {
+				taken++
+			}
+			now = now.Add(2 * time.Millisecond)
+		}
+		// Permissions are late by a millisecond on average, waiting for
+		// the next poll, but failed polls don't make them early.
+		assert.InDelta(t, 9100, taken, 200, "should preserve the average rate")
+	}
+}
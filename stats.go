@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// StatsLimiter is a Limiter that can be observed.
+//
+// All limiters returned by this package implement StatsLimiter.
+type StatsLimiter interface {
+	Limiter
+
+	// Stats returns a snapshot of the limiter's settings and state.
+	Stats() Stats
+}
+
+// Stats is a snapshot of a limiter.
+type Stats struct {
+	// Rate, Per and Slack are the current settings of the limiter.
+	Rate  Rate
+	Per   time.Duration
+	Slack int
+
+	// Available is the number of permissions that can be taken without
+	// waiting, which is at most Slack+1.
+	Available int
+	// NextPermission is the time at which the next permission is issued.
+	// It's the time of the snapshot if permissions are available, and
+	// zero if it depends on operations in flight.
+	NextPermission time.Time
+
+	// InFlight and MaxInFlight are only reported by ConcurrencyLimiter and
+	// Bulkhead: the number of operations that acquired a slot and haven't
+	// released it, and how many of them are allowed at once.
+	InFlight    int
+	MaxInFlight int
+
+	// The counters are only collected by limiters built WithStats.
+	//
+	// Takes is the number of permissions taken and not given back, and
+	// Throttled the number of times callers had to wait for permissions.
+	// Waited adds up how long they waited.
+	Takes     int64
+	Throttled int64
+	Waited    time.Duration
+}
+
+// newStats builds the stats of a limiter whose next permission is issued
+// at first, which is before now if permissions are available.
+func newStats(l *limits, c *counters, now, first time.Time) Stats {
+	s := Stats{
+		Rate:           l.rate,
+		Per:            l.per,
+		Slack:          l.slack,
+		NextPermission: first,
+	}
+	if first.After(now) {
+		return c.fill(s)
+	}
+
+	s.NextPermission = now
+	if l.perRequest > 0 {
+		s.Available = int(now.Sub(first)/l.perRequest) + 1
+	} else {
+		s.Available = math.MaxInt
+	}
+	return c.fill(s)
+}
+
+// counters collect the statistics of a limiter built WithStats.
+// Methods are no-ops on nil counters.
+type counters struct {
+	takes     atomic.Int64
+	throttled atomic.Int64
+	waited    atomic.Int64
+	//lint:ignore U1000 Padding is unused but it is crucial to maintain performance
+	// of this rate limiter in case of collocation with other frequently accessed memory.
+	padding [40]byte // cache line size - counters size = 64 - 24; created to avoid false sharing.
+}
+
+// record counts n permissions that were waited for d.
+// A negative n counts permissions given back.
+func (c *counters) record(n int, d time.Duration) {
+	if c == nil {
+		return
+	}
+	c.takes.Add(int64(n))
+	if d > 0 {
+		c.throttled.Add(1)
+		c.waited.Add(int64(d))
+	}
+}
+
+// fill adds the counters to s.
+func (c *counters) fill(s Stats) Stats {
+	if c == nil {
+		return s
+	}
+	s.Takes = c.takes.Load()
+	s.Throttled = c.throttled.Load()
+	s.Waited = time.Duration(c.waited.Load())
+	return s
+}
+
+type statsOption struct{}
+
+func (statsOption) apply(c *config) {
+	c.stats = true
+}
+
+// WithStats configures the limiter to count takes and waits, which are
+// reported by Stats. It's off by default to keep Take as cheap as possible.
+func WithStats() Option {
+	return statsOption{}
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VegasLimiter limits the number of operations in flight to a limit that
+// adapts to their latency, like TCP Vegas does, so that it doesn't need to
+// be tuned by hand.
+//
+// The lowest latency of the operations that succeeded recently is taken as
+// the latency of a downstream that isn't loaded. When operations take
+// longer than that, the difference is time spent queueing, and the limit
+// multiplied by the share of it in the latency estimates how many
+// operations are queued. The limit grows while few are, and shrinks when
+// many are, or when operations fail.
+type VegasLimiter struct {
+	slots    *ConcurrencyLimiter
+	min, max float64
+
+	mu    sync.Mutex
+	limit float64
+	// minRTT and prevMinRTT are the lowest latencies of the current
+	// baseline window, which started at windowStart, and of the previous
+	// one. They're zero if no operation succeeded in the window.
+	minRTT, prevMinRTT time.Duration
+	windowStart        time.Time
+	clock              Clock
+}
+
+// vegasWindow is how long the lowest latency of a VegasLimiter is
+// remembered for, at least, so that it adapts to a downstream whose
+// latency changed for good.
+const vegasWindow = time.Minute
+
+// NewVegas returns a VegasLimiter that starts with the given limit of
+// operations in flight, and adapts it between min and max, which must be
+// positive. WithClock and WithStats are the only options that apply.
+func NewVegas(limit, min, max int, opts ...Option) (*VegasLimiter, error) {
+	if !(0 < min && min <= limit && limit <= max) {
+		return nil, fmt.Errorf("ratelimit: limits must be 0 < min <= limit <= max, got %d, %d and %d", min, limit, max)
+	}
+	config := buildConfig(opts)
+	return &VegasLimiter{
+		slots: newConcurrency(limit, config.clock, config.counters()),
+		min:   float64(min),
+		max:   float64(max),
+		limit: float64(limit),
+		clock: config.clock,
+	}, nil
+}
+
+// Acquire blocks until an operation is allowed, or returns the error of
+// ctx if it's done first. The operation must report its outcome to the
+// returned Token once it's done.
+func (v *VegasLimiter) Acquire(ctx context.Context) (*Token, error) {
+	if err := v.slots.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	return v.newToken(), nil
+}
+
+// TryAcquire is like Acquire, but only succeeds if it doesn't have to
+// wait, and returns a nil Token otherwise.
+func (v *VegasLimiter) TryAcquire() (*Token, bool) {
+	if !v.slots.TryAcquire() {
+		return nil, false
+	}
+	return v.newToken(), true
+}
+
+func (v *VegasLimiter) newToken() *Token {
+	return &Token{
+		limiter:  v,
+		start:    v.clock.Now(),
+		inFlight: v.slots.Stats().InFlight,
+	}
+}
+
+// MinRTT returns the lowest latency of the operations that succeeded in
+// the last minute or two, or zero if none did.
+func (v *VegasLimiter) MinRTT() time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.rotate(v.clock.Now())
+	return v.baseline()
+}
+
+// Stats returns a snapshot of the limiter: its current limit is
+// MaxInFlight.
+func (v *VegasLimiter) Stats() Stats {
+	return v.slots.Stats()
+}
+
+// update adapts the limit to an operation that took rtt, with inFlight
+// operations in flight when it started.
+func (v *VegasLimiter) update(rtt time.Duration, inFlight int, failed bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Failures may be fast, like a refused connection, and say nothing
+	// about the latency of a downstream that isn't loaded.
+	v.rotate(v.clock.Now())
+	if !failed && rtt > 0 && (v.minRTT == 0 || rtt < v.minRTT) {
+		v.minRTT = rtt
+	}
+
+	// The thresholds and steps grow slowly with the limit, so that large
+	// limits adapt in reasonable time.
+	step := math.Max(1, math.Log10(v.limit))
+	alpha, beta := 3*step, 6*step
+
+	limit := v.limit
+	switch queued := v.queued(rtt); {
+	case failed:
+		limit -= step
+	case queued >= beta:
+		limit -= step
+	case queued <= alpha && float64(2*inFlight) >= v.limit:
+		// The limit only grows while it's used, or it could grow
+		// without bounds while the load is low.
+		limit += step
+	}
+	v.limit = math.Max(v.min, math.Min(v.max, limit))
+	v.slots.setMax(int(v.limit))
+}
+
+// queued estimates the number of operations queued in the downstream from
+// an operation that took rtt. It must be called with the lock held.
+func (v *VegasLimiter) queued(rtt time.Duration) float64 {
+	baseline := v.baseline()
+	if rtt <= 0 || baseline == 0 {
+		return 0
+	}
+	return v.limit * (1 - float64(baseline)/float64(rtt))
+}
+
+// rotate starts a new baseline window if the current one is over. It must
+// be called with the lock held.
+func (v *VegasLimiter) rotate(now time.Time) {
+	switch elapsed := now.Sub(v.windowStart); {
+	case elapsed < vegasWindow:
+		return
+	case elapsed < 2*vegasWindow:
+		v.prevMinRTT = v.minRTT
+	default:
+		v.prevMinRTT = 0
+	}
+	v.minRTT = 0
+	v.windowStart = now
+}
+
+// baseline returns the lowest latency of the current and previous baseline
+// windows. It must be called with the lock held.
+func (v *VegasLimiter) baseline() time.Duration {
+	if v.prevMinRTT != 0 && (v.minRTT == 0 || v.prevMinRTT < v.minRTT) {
+		return v.prevMinRTT
+	}
+	return v.minRTT
+}
+
+// Token stands for an operation allowed by a VegasLimiter.
+type Token struct {
+	limiter  *VegasLimiter
+	start    time.Time
+	inFlight int
+	done     int32
+}
+
+// Done reports that the operation is done, which frees its slot. The
+// operation failed if err isn't nil, which is taken as a sign of overload,
+// unless err is context.Canceled: the latency of cancelled operations is
+// ignored. Calling Done more than once has no further effect.
+func (t *Token) Done(err error) {
+	if !atomic.CompareAndSwapInt32(&t.done, 0, 1) {
+		return
+	}
+	if !errors.Is(err, context.Canceled) {
+		rtt := t.limiter.clock.Now().Sub(t.start)
+		t.limiter.update(rtt, t.inFlight, err != nil)
+	}
+	t.limiter.slots.Release()
+}
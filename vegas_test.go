@@ -0,0 +1,189 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVegasInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewVegas(10, 0, 20)
+	assert.EqualError(t, err, "ratelimit: limits must be 0 < min <= limit <= max, got 0, 10 and 20")
+	_, err = NewVegas(30, 1, 20)
+	assert.EqualError(t, err, "ratelimit: limits must be 0 < min <= limit <= max, got 1, 30 and 20")
+}
+
+// acquireN acquires n tokens of v without waiting.
+func acquireN(t *testing.T, v *VegasLimiter, n int) []*Token {
+	toks := make([]*Token, n)
+	for i := range toks {
+		tok, ok := v.TryAcquire()
+		require.True(t, ok, "acquire %d should be allowed", i)
+		toks[i] = tok
+	}
+	return toks
+}
+
+func TestVegasIncrease(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	v, err := NewVegas(10, 1, 20, WithClock(clk))
+	require.NoError(t, err)
+
+	toks := acquireN(t, v, 10)
+	_, ok := v.TryAcquire()
+	assert.False(t, ok)
+
+	// Operations don't queue, so the limit grows, but only from the
+	// operations that started while half of it was used.
+	clk.Add(10 * time.Millisecond)
+	for _, tok := range toks {
+		tok.Done(nil)
+	}
+	assert.Equal(t, 10*time.Millisecond, v.MinRTT())
+	assert.Equal(t, 16, v.Stats().MaxInFlight)
+	acquireN(t, v, 16)
+}
+
+func TestVegasIncreaseUnused(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	v, err := NewVegas(10, 1, 20, WithClock(clk))
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		tok, ok := v.TryAcquire()
+		require.True(t, ok)
+		clk.Add(10 * time.Millisecond)
+		tok.Done(nil)
+	}
+	assert.Equal(t, 10, v.Stats().MaxInFlight, "shouldn't grow while unused")
+}
+
+func TestVegasDecrease(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	v, err := NewVegas(10, 1, 20, WithClock(clk))
+	require.NoError(t, err)
+
+	tok, _ := v.TryAcquire()
+	clk.Add(10 * time.Millisecond)
+	tok.Done(nil)
+	assert.Equal(t, 10, v.Stats().MaxInFlight)
+
+	// Twice the latency leaves the limit as it is.
+	tok, _ = v.TryAcquire()
+	clk.Add(20 * time.Millisecond)
+	tok.Done(nil)
+	assert.Equal(t, 10, v.Stats().MaxInFlight)
+
+	// Ten times the latency means that most operations queue.
+	tok, _ = v.TryAcquire()
+	clk.Add(100 * time.Millisecond)
+	tok.Done(nil)
+	assert.Equal(t, 9, v.Stats().MaxInFlight)
+	assert.Equal(t, 10*time.Millisecond, v.MinRTT())
+}
+
+func TestVegasFailure(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	v, err := NewVegas(2, 1, 20, WithClock(clk))
+	require.NoError(t, err)
+
+	toks := acquireN(t, v, 2)
+	clk.Add(10 * time.Millisecond)
+
+	toks[0].Done(context.Canceled)
+	assert.Equal(t, 2, v.Stats().MaxInFlight, "cancellations should be ignored")
+	assert.Zero(t, v.MinRTT())
+
+	toks[0] = acquireN(t, v, 1)[0]
+	toks[0].Done(errors.New("unavailable"))
+	toks[0].Done(nil)
+	assert.Equal(t, 1, v.Stats().MaxInFlight)
+
+	// The operation still in flight holds the only slot.
+	_, ok := v.TryAcquire()
+	assert.False(t, ok)
+	toks[1].Done(context.Canceled)
+	acquireN(t, v, 1)
+}
+
+func TestVegasFastFailure(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	v, err := NewVegas(20, 1, 20, WithClock(clk))
+	require.NoError(t, err)
+
+	tok, _ := v.TryAcquire()
+	clk.Add(time.Millisecond)
+	tok.Done(errors.New("connection refused"))
+	assert.Zero(t, v.MinRTT(), "failures shouldn't set the baseline")
+	assert.Equal(t, 18, v.Stats().MaxInFlight)
+
+	// Healthy operations aren't taken as queued behind the failure.
+	for i := 0; i < 10; i++ {
+		toks := acquireN(t, v, 18)
+		clk.Add(100 * time.Millisecond)
+		for _, tok := range toks {
+			tok.Done(nil)
+		}
+	}
+	assert.Equal(t, 100*time.Millisecond, v.MinRTT())
+	assert.Equal(t, 20, v.Stats().MaxInFlight)
+}
+
+func TestVegasBaselineWindow(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	v, err := NewVegas(10, 1, 20, WithClock(clk))
+	require.NoError(t, err)
+
+	tok, _ := v.TryAcquire()
+	clk.Add(10 * time.Millisecond)
+	tok.Done(nil)
+	assert.Equal(t, 10*time.Millisecond, v.MinRTT())
+
+	// The downstream got slower for good: the old baseline is remembered
+	// for another window, then forgotten.
+	for i := 0; i < 3; i++ {
+		clk.Add(time.Minute)
+		tok, _ = v.TryAcquire()
+		clk.Add(50 * time.Millisecond)
+		tok.Done(nil)
+	}
+	assert.Equal(t, 50*time.Millisecond, v.MinRTT())
+
+	clk.Add(3 * time.Minute)
+	assert.Zero(t, v.MinRTT())
+}
+
+func TestVegasWaiters(t *testing.T) {
+	t.Parallel()
+	clk := newMockClock()
+	v, err := NewVegas(1, 1, 20, WithClock(clk))
+	require.NoError(t, err)
+
+	tok, _ := v.TryAcquire()
+	done := make(chan *Token)
+	go func() {
+		tok, err := v.Acquire(context.Background())
+		assert.NoError(t, err)
+		done <- tok
+	}()
+	waitForWaiters(t, v.slots, 1)
+
+	// The limit grows, which lets the waiter in.
+	clk.Add(10 * time.Millisecond)
+	tok.Done(nil)
+	tok = <-done
+	assert.Equal(t, 2, v.Stats().MaxInFlight)
+	assert.Equal(t, 1, v.Stats().InFlight)
+	tok.Done(nil)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit // import "go.uber.org/ratelimit"
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// warmup slows a new limiter down, from a fraction of its rate to all of
+// it. A nil warmup doesn't slow anything down.
+type warmup struct {
+	start       time.Time
+	duration    time.Duration
+	fraction    float64
+	exponential bool
+}
+
+// warmup returns the warmup of a limiter created now, if configured.
+func (c config) warmup(now time.Time) *warmup {
+	if c.warmupDuration == 0 {
+		return nil
+	}
+	return &warmup{
+		start:       now,
+		duration:    c.warmupDuration,
+		fraction:    c.warmupFraction,
+		exponential: c.warmupExponential,
+	}
+}
+
+// limits returns the limits that apply at now: while warming up, the rate
+// grows from its fraction to all of it, linearly or exponentially.
+func (w *warmup) limits(l *limits, now time.Time) *limits {
+	if w == nil {
+		return l
+	}
+	elapsed := now.Sub(w.start)
+	if elapsed >= w.duration {
+		return l
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	progress := float64(elapsed) / float64(w.duration)
+	fraction := w.fraction + (1-w.fraction)*progress
+	if w.exponential {
+		// The rate grows by the same factor in equal times.
+		fraction = math.Pow(w.fraction, 1-progress)
+	}
+	warm := newLimits(l.rate*Rate(fraction), l.per, l.slack)
+	return &warm
+}
+
+func checkWarmup(duration time.Duration, fraction float64) error {
+	if duration < 0 {
+		return fmt.Errorf("ratelimit: warmup must not be negative, got %v", duration)
+	}
+	if duration > 0 && !(fraction > 0 && fraction <= 1) {
+		return fmt.Errorf("ratelimit: warmup start fraction must be above 0 and at most 1, got %v", fraction)
+	}
+	return nil
+}
+
+type warmupOption struct {
+	duration    time.Duration
+	fraction    float64
+	exponential bool
+}
+
+func (o warmupOption) apply(c *config) {
+	c.warmupDuration = o.duration
+	c.warmupFraction = o.fraction
+	c.warmupExponential = o.exponential
+}
+
+// WithWarmup configures the limiter to start at a fraction of its rate,
+// above 0 and at most 1, and to ramp up linearly to all of it over the
+// given duration, like after a deploy or a cache flush, when the
+// resources it protects are cold. Slack accumulates at the rate of the
+// moment, which Stats reports.
+//
+// The warmup starts when the limiter is created. Rates changed with
+// SetRate or SetLimit in the meantime are warmed up too. It's supported
+// by the limiters returned by New and NewWithRate.
+func WithWarmup(duration time.Duration, startFraction float64) Option {
+	return warmupOption{duration: duration, fraction: startFraction}
+}
+
+// WithExponentialWarmup is like WithWarmup, but the rate grows
+// exponentially, by the same factor in equal times: starting from a tenth
+// of it over two minutes, the limiter is at about a third of its rate
+// after one. It stays slow for longer than with WithWarmup, and ramps up
+// fast at the end.
+func WithExponentialWarmup(duration time.Duration, startFraction float64) Option {
+	return warmupOption{duration: duration, fraction: startFraction, exponential: true}
+}
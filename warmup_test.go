@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmupInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewWithRate(10, WithWarmup(-time.Second, 0.5))
+	assert.EqualError(t, err, "ratelimit: warmup must not be negative, got -1s")
+	_, err = NewWithRate(10, WithWarmup(time.Second, 0))
+	assert.EqualError(t, err, "ratelimit: warmup start fraction must be above 0 and at most 1, got 0")
+	_, err = NewWithRate(10, WithWarmup(time.Second, 1.5))
+	assert.EqualError(t, err, "ratelimit: warmup start fraction must be above 0 and at most 1, got 1.5")
+	_, err = NewWithRate(10, WithExponentialWarmup(time.Second, 0))
+	assert.EqualError(t, err, "ratelimit: warmup start fraction must be above 0 and at most 1, got 0")
+	_, err = NewWithRate(10, WithWarmup(0, 0))
+	assert.NoError(t, err, "no warmup")
+}
+
+func TestWarmup(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithWarmup(10*time.Second, 0.1), WithoutSlack, WithStats())
+		clk := r.getClock()
+
+		// The limiter starts at a tenth of its rate.
+		ok, _ := rl.(TryLimiter).TryTake()
+		assert.True(t, ok)
+		ok, retryAfter := rl.(TryLimiter).TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, time.Second, retryAfter)
+		assert.Equal(t, Rate(1), rl.(StatsLimiter).Stats().Rate)
+
+		// Halfway through, it's at 55% of its rate.
+		clk.Add(5 * time.Second)
+		ok, _ = rl.(TryLimiter).TryTake()
+		assert.True(t, ok)
+		ok, retryAfter = rl.(TryLimiter).TryTake()
+		assert.False(t, ok)
+		assert.InDelta(t, time.Second/11*2, retryAfter, float64(time.Microsecond))
+		assert.InDelta(t, 5.5, float64(rl.(StatsLimiter).Stats().Rate), 1e-9)
+
+		// Then it runs at its full rate.
+		clk.Add(5 * time.Second)
+		ok, _ = rl.(TryLimiter).TryTake()
+		assert.True(t, ok)
+		ok, retryAfter = rl.(TryLimiter).TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, 100*time.Millisecond, retryAfter)
+		assert.Equal(t, Rate(10), rl.(StatsLimiter).Stats().Rate)
+	})
+}
+
+func TestExponentialWarmup(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(100, WithExponentialWarmup(10*time.Second, 0.01), WithoutSlack, WithStats())
+		clk := r.getClock()
+
+		// The rate is multiplied by 10 every 5 seconds.
+		for _, want := range []Rate{1, 10, 100} {
+			ok, _ := rl.(TryLimiter).TryTake()
+			assert.True(t, ok)
+			ok, retryAfter := rl.(TryLimiter).TryTake()
+			assert.False(t, ok)
+			assert.InDelta(t, time.Second/time.Duration(want), retryAfter, float64(time.Microsecond))
+			assert.InDelta(t, float64(want), float64(rl.(StatsLimiter).Stats().Rate), 1e-9)
+
+			clk.Add(5 * time.Second)
+		}
+	})
+}
+
+func TestWarmupLowRate(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		// A tenth of the lowest rate is too low to be tracked, and as low
+		// as can be instead.
+		rl := r.createLimiter(1e-9, WithWarmup(time.Hour, 0.1))
+
+		ok, _ := rl.(TryLimiter).TryTake()
+		assert.True(t, ok)
+		ok, retryAfter := rl.(TryLimiter).TryTake()
+		assert.False(t, ok)
+		assert.Equal(t, maxPerRequest, retryAfter)
+		assert.Zero(t, rl.(StatsLimiter).Stats().Available)
+	})
+}
+
+func TestWarmupTake(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithWarmup(2*time.Second, 0.5), WithoutSlack)
+
+		r.startTaking(rl)
+		// 5 per second at first, up to 10 per second after 2 seconds: 15
+		// permissions while warming up, and 20 in the 2 seconds after.
+		r.assertCountAt(time.Second, 7)
+		r.assertCountAt(4*time.Second, 35)
+	})
+}
+
+func TestWarmupSetRate(t *testing.T) {
+	t.Parallel()
+	runTest(t, func(r testRunner) {
+		rl := r.createLimiter(10, WithWarmup(10*time.Second, 0.5), WithoutSlack, WithStats())
+
+		// Rates set while warming up are warmed up too.
+		assert.NoError(t, rl.(AdjustableLimiter).SetRate(20))
+		assert.Equal(t, Rate(10), rl.(StatsLimiter).Stats().Rate)
+
+		r.getClock().Add(10 * time.Second)
+		assert.Equal(t, Rate(20), rl.(StatsLimiter).Stats().Rate)
+	})
+}